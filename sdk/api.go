@@ -1,22 +1,35 @@
 package sdk
 
 import (
+	"context"
 	"fmt"
 	"github.com/Zecrey-Labs/zecrey-marketplace-go-sdk/sdk/model"
-	"github.com/zecrey-labs/zecrey-crypto/util/eddsaHelper"
-	"github.com/zecrey-labs/zecrey-eth-rpc/_rpc"
 	"math/big"
+	"time"
 )
 
 type ZecreyNftMarketSDK interface {
+	// GetMyInfo returns the seed too, which is empty for clients built from a
+	// KeyManager. Prefer GetPublicInfo.
 	GetMyInfo() (accountName string, l2pk string, seed string)
 
+	GetPublicInfo() (accountName string, l2pk string)
+
+	// Close releases the client and zeroes the keys it owns.
+	Close() error
+
 	CreateCollection(ShortName string, CategoryId string, CreatorEarningRate string,
 		ops ...model.CollectionOption) (*RespCreateCollection, error)
 
+	CreateCollectionWithContext(ctx context.Context, ShortName string, CategoryId string, CreatorEarningRate string,
+		ops ...model.CollectionOption) (*RespCreateCollection, error)
+
 	UpdateCollection(Id string, Name string,
 		ops ...model.CollectionOption) (*RespUpdateCollection, error)
 
+	UpdateCollectionWithContext(ctx context.Context, Id string, Name string,
+		ops ...model.CollectionOption) (*RespUpdateCollection, error)
+
 	MintNft(
 		CollectionId int64,
 		NftUrl string, Name string,
@@ -24,39 +37,90 @@ type ZecreyNftMarketSDK interface {
 		Properties string, Levels string, Stats string,
 	) (*RespCreateAsset, error)
 
+	MintNftWithContext(ctx context.Context,
+		CollectionId int64,
+		NftUrl string, Name string,
+		Description string, Media string,
+		Properties string, Levels string, Stats string,
+	) (*RespCreateAsset, error)
+
 	TransferNft(AssetId int64, toAccountName string) (*ResqSendTransferNft, error)
 
+	TransferNftWithContext(ctx context.Context, AssetId int64, toAccountName string) (*ResqSendTransferNft, error)
+
 	WithdrawNft(AssetId int64) (*ResqSendWithdrawNft, error)
 
+	WithdrawNftWithContext(ctx context.Context, AssetId int64) (*ResqSendWithdrawNft, error)
+
+	// Transfer sends amount of the fungible asset assetId to toAccountName.
+	Transfer(toAccountName string, assetId int64, amount *big.Int, memo string) (*RespSendTx, error)
+
+	TransferWithContext(ctx context.Context, toAccountName string, assetId int64, amount *big.Int, memo string) (*RespSendTx, error)
+
+	// Withdraw withdraws amount of the fungible asset assetId to l1Address,
+	// which must carry its EIP-55 checksum.
+	Withdraw(assetId int64, amount *big.Int, l1Address string) (*RespSendTx, error)
+
+	WithdrawWithContext(ctx context.Context, assetId int64, amount *big.Int, l1Address string) (*RespSendTx, error)
+
+	GetWithdrawStatus(txId string) (*WithdrawStatus, error)
+
+	GetWithdrawStatusWithContext(ctx context.Context, txId string) (*WithdrawStatus, error)
+
+	// WaitWithdrawClaimable polls the withdrawal txId every pollInterval until
+	// it is claimable on L1.
+	WaitWithdrawClaimable(txId string, pollInterval time.Duration) (*WithdrawStatus, error)
+
+	WaitWithdrawClaimableWithContext(ctx context.Context, txId string, pollInterval time.Duration) (*WithdrawStatus, error)
+
 	CreateSellOffer(AssetId int64, AssetType int64, AssetAmount *big.Int) (*RespListOffer, error)
 
+	CreateSellOfferWithContext(ctx context.Context, AssetId int64, AssetType int64, AssetAmount *big.Int) (*RespListOffer, error)
+
 	CreateBuyOffer(AssetId int64, AssetType int64, AssetAmount *big.Int) (*RespListOffer, error)
 
+	CreateBuyOfferWithContext(ctx context.Context, AssetId int64, AssetType int64, AssetAmount *big.Int) (*RespListOffer, error)
+
 	CancelOffer(offerId int64) (*RespCancelOffer, error)
 
+	CancelOfferWithContext(ctx context.Context, offerId int64) (*RespCancelOffer, error)
+
 	AcceptOffer(offerId int64, isSell bool, AssetAmount *big.Int) (*RespAcceptOffer, error)
 
+	AcceptOfferWithContext(ctx context.Context, offerId int64, isSell bool, AssetAmount *big.Int) (*RespAcceptOffer, error)
+
 	SignTx(msgHash []byte) ([]byte, error)
+
+	SignTxWithContext(ctx context.Context, msgHash []byte) ([]byte, error)
 }
 
-//NewZecreyMarketplaceClient public
+//NewZecreyMarketplaceClient public, uses the config set by SetConfig
 func NewZecreyMarketplaceClient(accountName, seed string) (ZecreyNftMarketSDK, error) {
-	keyManager, err := NewSeedKeyManager(seed)
-	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("wrong seed:%s", seed))
+	return NewZecreyMarketplaceClientWithConfig(GetConfig(), accountName, seed)
+}
+
+//NewZecreyMarketplaceClientWithConfig public
+func NewZecreyMarketplaceClientWithConfig(cfg *Config, accountName, seed string) (ZecreyNftMarketSDK, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return newZecreyMarketplaceClientWithSeed(cfg, accountName, seed)
+}
+
+//NewZecreyMarketplaceClientWithKeyManager public, the client never sees the
+//seed: l2pk is derived from key.Public(). Close closes key when it implements io.Closer.
+func NewZecreyMarketplaceClientWithKeyManager(cfg *Config, accountName string, key KeyManager) (ZecreyNftMarketSDK, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("key manager is required")
 	}
-	l2pk := eddsaHelper.GetEddsaPublicKey(seed[2:])
-	connEth, err := _rpc.NewClient(chainRpcUrl)
+	connEth, err := dialChain(context.Background(), cfg)
 	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("wrong rpc url:%s", chainRpcUrl))
+		return nil, err
 	}
-	return &client{
-		accountName:    fmt.Sprintf("%s%s", accountName, NameSuffix),
-		seed:           seed,
-		l2pk:           l2pk,
-		nftMarketUrl:   nftMarketUrl,
-		legendUrl:      legendUrl,
-		providerClient: connEth,
-		keyManager:     keyManager,
-	}, nil
+	c := newClientWithKeyManager(cfg, connEth, accountName, key, l2pkOfKey(key))
+	c.ownsKey = true
+	return c, nil
 }
@@ -1,14 +1,15 @@
 package sdk
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"math/big"
-	"net/http"
 	"net/url"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
@@ -17,31 +18,19 @@ import (
 
 	"github.com/Zecrey-Labs/zecrey-marketplace-go-sdk/sdk/model"
 	"github.com/zecrey-labs/zecrey-eth-rpc/_rpc"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
-	//nftMarketUrl = "http://localhost:9999"
-	nftMarketUrl = "https://test-legend-nft.zecrey.com"
-	//nftMarketUrl = "https://dev-legend-nft.zecrey.com"
-	//nftMarketUrl = "https://qa-legend-nft.zecrey.com"
-
-	legendUrl = "https://qa-legend-app.zecrey.com"
-	//legendUrl = "https://dev-legend-app.zecrey.com"
-	//legendUrl    = "https://test-legend-app.zecrey.com"
-
-	//hasuraUrl          = "https://legend-market-dev.hasura.app/v1/graphql"
-	hasuraUrl = "https://legend-market-qa.hasura.app/v1/graphql"
-	//hasuraUrl          = "https://legend-marketplace.hasura.app/v1/graphql"
-	hasuraAdminKey = "M5tpo0dWWjYdW0erD0mHqwcRSObUowSprpS7Q3K33SNQ0dcXkPeL63tpoka9dTBw" //qa
-	//hasuraAdminKey     = "j76XNG0u72QWBt4gS167wJlhnFNHSI5A6R1427KGJyMrFWI7s8wOvz1vmA4DsGos"//test
 	hasuraTimeDeadline = 15 //15s
+	mediaUploadTimeout = 10 * time.Second
 
-	chainRpcUrl     = "https://data-seed-prebsc-1-s1.binance.org:8545"
 	DefaultGasLimit = 5000000
 	NameSuffix      = ".zec"
 )
 
 type client struct {
+	cfg            *Config
 	accountName    string
 	l2pk           string
 	seed           string
@@ -49,81 +38,79 @@ type client struct {
 	legendUrl      string
 	providerClient *_rpc.ProviderClient
 	keyManager     KeyManager
+	// ownsKey is false for the clients of a Wallet, which closes their keys
+	ownsKey bool
+	// keyLock guards keyManager, seed and providerClient. Signatures hold it
+	// for reading, so Close waits for them before closing the key.
+	keyLock sync.RWMutex
+	// txLock serializes the txs signed by the client, from reading the next
+	// nonce or offer id to submitting the tx
+	txLock sync.Mutex
 }
 
 func (c *client) SetKeyManager(keyManager KeyManager) {
+	c.keyLock.Lock()
+	defer c.keyLock.Unlock()
 	c.keyManager = keyManager
 }
 
+// withKey calls sign with the key manager of c bound to ctx, holding keyLock
+// until sign returns.
+func (c *client) withKey(ctx context.Context, sign func(key KeyManager) error) error {
+	c.keyLock.RLock()
+	defer c.keyLock.RUnlock()
+	return sign(withContext(ctx, c.keyManager))
+}
+
+func (c *client) logger() Logger {
+	c.keyLock.RLock()
+	defer c.keyLock.RUnlock()
+	return c.cfg.logger(c.seed)
+}
+
 func (c *client) CreateCollection(ShortName string, CategoryId string, CreatorEarningRate string, ops ...model.CollectionOption) (*RespCreateCollection, error) {
-	cp := &model.CollectionParams{}
-	for _, do := range ops {
-		do.F(cp)
-	}
+	return c.CreateCollectionWithContext(context.Background(), ShortName, CategoryId, CreatorEarningRate, ops...)
+}
 
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/preparetx/getPrepareCreateCollectionTxInfo?account_name=%s", c.accountName))
-	if err != nil {
-		return nil, err
-	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
-	if err != nil {
-		return nil, err
-	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
+func (c *client) CreateCollectionWithContext(ctx context.Context, ShortName string, CategoryId string, CreatorEarningRate string, ops ...model.CollectionOption) (_ *RespCreateCollection, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "CreateCollection", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
 
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
-	tx, err := PrepareCreateCollectionTxInfo(c.keyManager, resultPrepare.Transtion, cp.Description)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/collection/createCollection",
-		url.Values{"short_name": {ShortName},
-			"category_id":          {CategoryId},
-			"collection_url":       {cp.CollectionUrl},
-			"external_link":        {cp.ExternalLink},
-			"twitter_link":         {cp.TwitterLink},
-			"instagram_link":       {cp.TelegramLink},
-			"discord_link":         {cp.InstagramLink},
-			"telegram_link":        {cp.DiscordLink},
-			"logo_image":           {cp.LogoImage},
-			"featured_image":       {cp.FeaturedImage},
-			"banner_image":         {cp.BannerImage},
-			"creator_earning_rate": {CreatorEarningRate},
-			"payment_asset_ids":    {cp.PaymentAssetIds},
-			"transaction":          {tx}})
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespCreateCollection{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	env, err := c.prepareAndSubmit(ctx, func(ctx context.Context) (*Envelope, error) {
+		return prepareCreateCollection(ctx, c.cfg, c.accountName, ShortName, CategoryId, CreatorEarningRate, ops...)
+	}, result)
+	if err != nil {
 		return nil, err
 	}
+	result.GasFee = env.GasFee
 	return result, nil
 }
 
 func (c *client) UpdateCollection(Id string, Name string, ops ...model.CollectionOption) (*RespUpdateCollection, error) {
+	return c.UpdateCollectionWithContext(context.Background(), Id, Name, ops...)
+}
+
+func (c *client) UpdateCollectionWithContext(ctx context.Context, Id string, Name string, ops ...model.CollectionOption) (_ *RespUpdateCollection, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "UpdateCollection", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
+
 	cp := &model.CollectionParams{}
 	for _, do := range ops {
 		do.F(cp)
 	}
 	CategoryId := "1"
 	timestamp := time.Now().Unix()
-	message := fmt.Sprintf("%dupdate_collection", timestamp)
-	signature := SignMessage(c.keyManager, message)
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/collection/updateCollection",
+	var signature string
+	err = c.withKey(ctx, func(key KeyManager) (err error) {
+		signature, err = signTypedMessage(key, &Message{Action: MessageUpdateCollection, Timestamp: timestamp})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &RespUpdateCollection{}
+	err = httpPostForm(ctx, c.cfg, c.nftMarketUrl+"/api/v1/collection/updateCollection",
 		url.Values{
 			"id":             {Id},
 			"account_name":   {c.accountName},
@@ -141,338 +128,256 @@ func (c *client) UpdateCollection(Id string, Name string, ops ...model.Collectio
 			"banner_image":   {cp.BannerImage},
 			"timestamp":      {fmt.Sprintf("%d", timestamp)},
 			"signature":      {signature}},
-	)
+		result)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	result := &RespUpdateCollection{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
 	return result, nil
 }
 
 func (c *client) MintNft(CollectionId int64, NftUrl string, Name string, Description string, Media string, Properties string, Levels string, Stats string) (*RespCreateAsset, error) {
+	return c.MintNftWithContext(context.Background(), CollectionId, NftUrl, Name, Description, Media, Properties, Levels, Stats)
+}
 
-	ContentHash, err := calculateContentHash(c.accountName, CollectionId, Name, Properties, Levels, Stats)
-
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/preparetx/getPrepareMintNftTxInfo?account_name=%s&collection_id=%d&name=%s&content_hash=%s", c.accountName, CollectionId, Name, ContentHash))
-	if err != nil {
-		return nil, err
-	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
-	if err != nil {
-		return nil, err
-	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
-	tx, err := PrepareMintNftTxInfo(c.keyManager, resultPrepare.Transtion)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/asset/createAsset",
-		url.Values{
-			"collection_id": {fmt.Sprintf("%d", CollectionId)},
-			"nft_url":       {NftUrl},
-			"name":          {Name},
-			"description":   {Description},
-			"media":         {Media},
-			"properties":    {Properties},
-			"levels":        {Levels},
-			"stats":         {Stats},
-			"transaction":   {tx},
-		},
-	)
+func (c *client) MintNftWithContext(ctx context.Context, CollectionId int64, NftUrl string, Name string, Description string, Media string, Properties string, Levels string, Stats string) (_ *RespCreateAsset, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "MintNft", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
 
-	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespCreateAsset{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	env, err := c.prepareAndSubmit(ctx, func(ctx context.Context) (*Envelope, error) {
+		return prepareMintNft(ctx, c.cfg, c.accountName, CollectionId, NftUrl, Name, Description, Media, Properties, Levels, Stats)
+	}, result)
+	if err != nil {
 		return nil, err
 	}
+	result.GasFee = env.GasFee
 	return result, nil
 }
 
 func (c *client) TransferNft(
 	AssetId int64,
 	toAccountName string) (*ResqSendTransferNft, error) {
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/preparetx/getPrepareTransferNftTxInfo?account_name=%s&to_account_name=%s%s&nft_id=%d", c.accountName, toAccountName, NameSuffix, AssetId))
-	if err != nil {
-		return nil, err
-	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
-	if err != nil {
-		return nil, err
-	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
+	return c.TransferNftWithContext(context.Background(), AssetId, toAccountName)
+}
 
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
-	txInfo, err := PrepareTransferNftTxInfo(c.keyManager, resultPrepare.Transtion)
+func (c *client) TransferNftWithContext(ctx context.Context,
+	AssetId int64,
+	toAccountName string) (_ *ResqSendTransferNft, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "TransferNft", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
 
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/asset/sendTransferNft",
-		url.Values{
-			"asset_id":    {fmt.Sprintf("%d", AssetId)},
-			"transaction": {txInfo},
-		},
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &ResqSendTransferNft{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	env, err := c.prepareAndSubmit(ctx, func(ctx context.Context) (*Envelope, error) {
+		return prepareTransferNft(ctx, c.cfg, c.accountName, AssetId, toAccountName)
+	}, result)
+	if err != nil {
 		return nil, err
 	}
+	result.GasFee = env.GasFee
 	return result, nil
 }
 
 func (c *client) WithdrawNft(AssetId int64) (*ResqSendWithdrawNft, error) {
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/preparetx/getPrepareWithdrawNftTxInfo?account_name=%s&nft_id=%d", c.accountName, AssetId))
-	if err != nil {
-		return nil, err
-	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
-	if err != nil {
-		return nil, err
-	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
+	return c.WithdrawNftWithContext(context.Background(), AssetId)
+}
+
+func (c *client) WithdrawNftWithContext(ctx context.Context, AssetId int64) (_ *ResqSendWithdrawNft, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "WithdrawNft", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
 
-	txInfo, err := PrepareWithdrawNftTxInfo(c.keyManager, resultPrepare.Transtion)
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/asset/sendWithdrawNft",
-		url.Values{
-			"asset_id":    {fmt.Sprintf("%d", AssetId)},
-			"transaction": {txInfo},
-		},
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &ResqSendWithdrawNft{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	env, err := c.prepareAndSubmit(ctx, func(ctx context.Context) (*Envelope, error) {
+		return prepareWithdrawNft(ctx, c.cfg, c.accountName, AssetId)
+	}, result)
+	if err != nil {
 		return nil, err
 	}
+	result.GasFee = env.GasFee
 	return result, nil
 }
 
 func (c *client) CreateSellOffer(AssetId int64, AssetType int64, AssetAmount *big.Int) (*RespListOffer, error) {
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/preparetx/getPrepareOfferTxInfo?account_name=%s&nft_id=%d&money_id=%d&money_amount=%d&is_sell=true", c.accountName, AssetId, AssetType, AssetAmount))
-	if err != nil {
-		return nil, err
-	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
-	if err != nil {
-		return nil, err
-	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
+	return c.CreateSellOfferWithContext(context.Background(), AssetId, AssetType, AssetAmount)
+}
+
+func (c *client) CreateSellOfferWithContext(ctx context.Context, AssetId int64, AssetType int64, AssetAmount *big.Int) (_ *RespListOffer, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "CreateSellOffer", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
 
-	tx, err := PrepareOfferTxInfo(c.keyManager, resultPrepare.Transtion, true)
+	result := &RespListOffer{}
+	_, err = c.prepareAndSubmit(ctx, func(ctx context.Context) (*Envelope, error) {
+		return prepareOffer(ctx, c.cfg, c.accountName, AssetId, AssetType, AssetAmount, true)
+	}, result)
 	if err != nil {
 		return nil, err
 	}
-	return c.Offer(c.accountName, tx)
+	return result, nil
 }
 
 func (c *client) CreateBuyOffer(AssetId int64, AssetType int64, AssetAmount *big.Int) (*RespListOffer, error) {
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/preparetx/getPrepareOfferTxInfo?account_name=%s&nft_id=%d&money_id=%d&money_amount=%d&is_sell=false", c.accountName, AssetId, AssetType, AssetAmount))
-	if err != nil {
-		return nil, err
-	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
-	if err != nil {
-		return nil, err
-	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
-	tx, err := PrepareOfferTxInfo(c.keyManager, resultPrepare.Transtion, false)
+	return c.CreateBuyOfferWithContext(context.Background(), AssetId, AssetType, AssetAmount)
+}
+
+func (c *client) CreateBuyOfferWithContext(ctx context.Context, AssetId int64, AssetType int64, AssetAmount *big.Int) (_ *RespListOffer, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "CreateBuyOffer", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
+
+	result := &RespListOffer{}
+	_, err = c.prepareAndSubmit(ctx, func(ctx context.Context) (*Envelope, error) {
+		return prepareOffer(ctx, c.cfg, c.accountName, AssetId, AssetType, AssetAmount, false)
+	}, result)
 	if err != nil {
 		return nil, err
 	}
-	return c.Offer(c.accountName, tx)
+	return result, nil
 }
 
 func (c *client) CancelOffer(offerId int64) (*RespCancelOffer, error) {
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/offer/xxxxxxxx?offerId=%d", offerId))
+	return c.CancelOfferWithContext(context.Background(), offerId)
+}
+
+func (c *client) CancelOfferWithContext(ctx context.Context, offerId int64) (_ *RespCancelOffer, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "CancelOffer", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
+
+	// the marketplace prepares no cancel txs, they are always assembled locally
+	c.txLock.Lock()
+	defer c.txLock.Unlock()
+	tx, err := assembleCancelOfferTx(ctx, c.cfg, c.accountName, offerId)
 	if err != nil {
 		return nil, err
 	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
+	var txInfo string
+	err = c.withKey(ctx, func(key KeyManager) (err error) {
+		txInfo, err = ConstructCancelOfferTx(key, tx)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
-	tx, err := PrepareOfferTxInfo(c.keyManager, resultPrepare.Transtion, false)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/offer/cancelOffer",
+	result := &RespCancelOffer{}
+	err = httpSubmitForm(ctx, c.cfg, c.nftMarketUrl+"/api/v1/offer/cancelOffer",
 		url.Values{
 			"id":          {fmt.Sprintf("%d", offerId)},
-			"transaction": {tx},
+			"transaction": {txInfo},
 		},
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
+		result)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	result := &RespCancelOffer{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
 	return result, nil
-
 }
 
 func (c *client) Offer(accountName string, tx string) (*RespListOffer, error) {
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/offer/listOffer",
+	return c.OfferWithContext(context.Background(), accountName, tx)
+}
+
+func (c *client) OfferWithContext(ctx context.Context, accountName string, tx string) (_ *RespListOffer, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "Offer", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
+
+	result := &RespListOffer{}
+	err = httpPostForm(ctx, c.cfg, c.nftMarketUrl+"/api/v1/offer/listOffer",
 		url.Values{
 			"accountName": {accountName},
 			"transaction": {tx},
 		},
-	)
+		result)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	result := &RespListOffer{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
 	return result, nil
 }
 
 func (c *client) AcceptOffer(offerId int64, isSell bool, AssetAmount *big.Int) (*RespAcceptOffer, error) {
-	respPrepareTx, err := http.Get(c.nftMarketUrl + fmt.Sprintf("/api/v1/preparetx/getPrepareAtomicMatchWithTx?account_name=%s&offer_id=%d&money_id=%d&money_amount=%s&is_sell=%v", c.accountName, offerId, 0, AssetAmount.String(), isSell))
-	if err != nil {
-		return nil, err
-	}
-	body, err := ioutil.ReadAll(respPrepareTx.Body)
-	if err != nil {
-		return nil, err
-	}
-	if respPrepareTx.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	resultPrepare := &RespetPreparetxInfo{}
-	if err := json.Unmarshal(body, &resultPrepare); err != nil {
-		return nil, err
-	}
+	return c.AcceptOfferWithContext(context.Background(), offerId, isSell, AssetAmount)
+}
 
-	txInfo, err := PrepareAtomicMatchWithTx(c.keyManager, resultPrepare.Transtion, isSell, AssetAmount)
+func (c *client) AcceptOfferWithContext(ctx context.Context, offerId int64, isSell bool, AssetAmount *big.Int) (_ *RespAcceptOffer, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "AcceptOffer", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
+
+	result := &RespAcceptOffer{}
+	env, err := c.prepareAndSubmit(ctx, func(ctx context.Context) (*Envelope, error) {
+		return prepareAcceptOffer(ctx, c.cfg, c.accountName, offerId, isSell, AssetAmount)
+	}, result)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.PostForm(c.nftMarketUrl+"/api/v1/offer/acceptOffer",
-		url.Values{
-			"id":          {fmt.Sprintf("%d", offerId)},
-			"transaction": {txInfo},
-		},
-	)
+	result.GasFee = env.GasFee
+	return result, nil
+}
+
+// prepareAndSubmit prepares an envelope with prepare, signs it with the key
+// of c and submits it, holding txLock so that concurrent operations of c do
+// not read the same nonce or offer id.
+func (c *client) prepareAndSubmit(ctx context.Context, prepare func(ctx context.Context) (*Envelope, error), result interface{}) (*Envelope, error) {
+	c.txLock.Lock()
+	defer c.txLock.Unlock()
+	env, err := prepare(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
+	err = c.withKey(ctx, func(key KeyManager) error {
+		return SignEnvelopeWithContext(ctx, key, env)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
-	result := &RespAcceptOffer{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := submitEnvelope(ctx, c.cfg, env, result); err != nil {
 		return nil, err
 	}
-	return result, nil
+	return env, nil
 }
 
 /*
 GetMyInfo accountName、l2pk、seed
 */
 func (c *client) GetMyInfo() (accountName string, l2pk string, seed string) {
+	c.keyLock.RLock()
+	defer c.keyLock.RUnlock()
 	return c.accountName, c.l2pk, c.seed
 }
 
+// GetPublicInfo accountName、l2pk
+func (c *client) GetPublicInfo() (accountName string, l2pk string) {
+	return c.accountName, c.l2pk
+}
+
+// Close drops the seed and closes the key manager and the chain connection
+// the client owns. Go strings can not be wiped, so the seed passed to
+// NewZecreyMarketplaceClient may remain in memory until collected; prefer
+// NewZecreyMarketplaceClientWithKeyManager with a KeystoreKeyManager.
+func (c *client) Close() error {
+	c.keyLock.Lock()
+	defer c.keyLock.Unlock()
+	var err error
+	if c.ownsKey {
+		if closer, ok := c.keyManager.(io.Closer); ok {
+			err = closer.Close()
+		}
+		if c.providerClient != nil {
+			c.providerClient.Close()
+		}
+	}
+	c.seed = ""
+	c.keyManager = closedKeyManager{}
+	c.providerClient = nil
+	return err
+}
+
 func (c *client) SignTx(msgHash []byte) ([]byte, error) {
+	return c.SignTxWithContext(context.Background(), msgHash)
+}
+
+func (c *client) SignTxWithContext(ctx context.Context, msgHash []byte) ([]byte, error) {
 	hFunc := mimc.NewMiMC()
 	hFunc.Reset()
-	signature, err := c.keyManager.Sign(msgHash, hFunc)
+	var signature []byte
+	err := c.withKey(ctx, func(key KeyManager) (err error) {
+		signature, err = key.Sign(msgHash, hFunc)
+		return err
+	})
 	if err != nil {
 		return []byte(""), err
 	}
@@ -485,8 +390,6 @@ func PrepareCreateCollectionTxInfo(key KeyManager, txInfoPrepare, Description st
 	if err != nil {
 		return "", err
 	}
-	//reset
-	txInfo.GasFeeAssetAmount = big.NewInt(1000000000000000)
 	txInfo.Introduction = Description
 	tx, err := ConstructCreateCollectionTx(key, txInfo) //sign tx message
 	if err != nil {
@@ -501,7 +404,6 @@ func PrepareMintNftTxInfo(key KeyManager, txInfoPrepare string) (string, error)
 	if err != nil {
 		return "", err
 	}
-	txInfo.GasFeeAssetAmount = big.NewInt(1000000000000000)
 	tx, err := ConstructMintNftTx(key, txInfo)
 	if err != nil {
 		return "", err
@@ -515,7 +417,6 @@ func PrepareTransferNftTxInfo(key KeyManager, txInfoPrepare string) (string, err
 	if err != nil {
 		return "", err
 	}
-	txInfo.GasFeeAssetAmount = big.NewInt(1000000000000000)
 	tx, err := ConstructTransferNftTx(key, txInfo)
 	if err != nil {
 		return "", err
@@ -529,23 +430,38 @@ func PrepareAtomicMatchWithTx(key KeyManager, txInfoPrepare string, isSell bool,
 	if err != nil {
 		return "", err
 	}
+	if txInfo.BuyOffer == nil || txInfo.SellOffer == nil {
+		return "", fmt.Errorf("atomic match tx misses an offer")
+	}
+	// sign the offer with the accepted amount, so that the signature and the
+	// signing policy cover the amount actually sent
 	if !isSell {
+		if AssetAmount != nil {
+			txInfo.BuyOffer.AssetAmount = AssetAmount
+		}
 		signedTx, err := ConstructOfferTx(key, txInfo.BuyOffer)
 		if err != nil {
 			return "", err
 		}
-		signedOffer, _ := ParseOfferTxInfo(signedTx)
+		signedOffer, err := ParseOfferTxInfo(signedTx)
+		if err != nil {
+			return "", err
+		}
 		txInfo.BuyOffer = signedOffer
-		txInfo.BuyOffer.AssetAmount = AssetAmount
 	}
 	if isSell {
+		if AssetAmount != nil {
+			txInfo.SellOffer.AssetAmount = AssetAmount
+		}
 		signedTx, err := ConstructOfferTx(key, txInfo.SellOffer)
 		if err != nil {
 			return "", err
 		}
-		signedOffer, _ := ParseOfferTxInfo(signedTx)
+		signedOffer, err := ParseOfferTxInfo(signedTx)
+		if err != nil {
+			return "", err
+		}
 		txInfo.SellOffer = signedOffer
-		txInfo.SellOffer.AssetAmount = AssetAmount
 	}
 
 	tx, err := ConstructAtomicMatchTx(key, txInfo)
@@ -561,7 +477,6 @@ func PrepareWithdrawNftTxInfo(key KeyManager, txInfoPrepare string) (string, err
 	if err != nil {
 		return "", err
 	}
-	txInfo.GasFeeAssetAmount = big.NewInt(1000000000000000)
 	tx, err := ConstructWithdrawNftTx(key, txInfo)
 	if err != nil {
 		return "", err
@@ -659,18 +574,53 @@ func calculateContentHash(accountName string, collectionId int64, name string, _
 	hFunc := mimc.NewMiMC()
 	hFunc.Write([]byte(content))
 	bytes := crypto.Keccak256Hash([]byte(content))
-	fmt.Println("==nft content ==", content)
 	return common.Bytes2Hex(bytes[:]), nil
 }
 
 func SignMessage(key KeyManager, message string) string {
-	fmt.Println("message: ", message)
-	sig, err := key.Sign([]byte(message), mimc.NewMiMC())
+	signed, err := signMessage(key, message)
 	if err != nil {
 		panic("failed to sign message, err: " + err.Error())
 	}
+	return signed
+}
+
+// signTypedMessage signs msg, handing it over to key when it can inspect it.
+func signTypedMessage(key KeyManager, msg *Message) (string, error) {
+	var (
+		sig []byte
+		err error
+	)
+	if signer, ok := key.(MessageKeyManager); ok {
+		sig, err = signer.SignMessage(context.Background(), msg, mimc.NewMiMC())
+	} else {
+		sig, err = key.Sign(msg.Bytes(), mimc.NewMiMC())
+	}
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+func signMessage(key KeyManager, message string) (string, error) {
+	sig, err := key.Sign([]byte(message), mimc.NewMiMC())
+	if err != nil {
+		return "", err
+	}
 
 	signed := hex.EncodeToString(sig[:])
-	fmt.Println("signed:", signed)
-	return signed
+	return signed, nil
+}
+
+// newClientWithKeyManager returns a client signing with key, it never holds the seed.
+func newClientWithKeyManager(cfg *Config, providerClient *_rpc.ProviderClient, accountName string, key KeyManager, l2pk string) *client {
+	return &client{
+		cfg:            cfg,
+		accountName:    fmt.Sprintf("%s%s", accountName, NameSuffix),
+		l2pk:           l2pk,
+		nftMarketUrl:   cfg.NftMarketUrl,
+		legendUrl:      cfg.LegendUrl,
+		providerClient: providerClient,
+		keyManager:     key,
+	}
 }
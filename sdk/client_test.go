@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+)
+
+func TestClientWithKeyManager(t *testing.T) {
+	seed := "0x" + strings.Repeat("ef", 32)
+	key, err := NewSeedKeyManager(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewZecreyMarketplaceClientWithKeyManager(DefaultConfig(), "alice", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2pk, err := l2pkOfSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountName, pk := c.GetPublicInfo()
+	if accountName != "alice"+NameSuffix || pk != l2pk {
+		t.Fatalf("unexpected info %s %s, expected l2pk %s", accountName, pk, l2pk)
+	}
+	if _, _, s := c.GetMyInfo(); s != "" {
+		t.Fatal("seed-free client returned a seed")
+	}
+	if _, err := c.SignTx([]byte("hash")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SignTx([]byte("hash")); !errors.Is(err, ErrKeyClosed) {
+		t.Fatalf("closed client signed: %v", err)
+	}
+	if _, err := key.Sign([]byte("hash"), nil); !errors.Is(err, ErrKeyClosed) {
+		t.Fatalf("closed key signed: %v", err)
+	}
+}
+
+func TestClientCloseWhileSigning(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ef", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := parseAccountPk(l2pkOfKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClientWithKeyManager(DefaultConfig(), nil, "alice", key, l2pkOfKey(key))
+	c.ownsKey = true
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				sig, err := c.SignTx([]byte("hash"))
+				if errors.Is(err, ErrKeyClosed) {
+					return
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				// a key zeroed mid signature would produce an invalid one
+				if ok, _ := pk.Verify(sig, []byte("hash"), mimc.NewMiMC()); !ok {
+					t.Error("invalid signature while closing")
+					return
+				}
+			}
+		}()
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,236 @@
+// Command offline runs the air-gapped signing workflow of sdk.Envelope in
+// three steps, each reading and writing an envelope file:
+//
+//	# online: fetch the unsigned tx, or assemble it with -assembly local
+//	offline prepare -op TransferNft -account alice -asset-id 12 -to bob -out tx.json
+//	# offline: sign with a keystore, its password is read from ZECREY_KEYSTORE_PASSWORD
+//	offline sign -in tx.json -keystore /keys/alice.json -out tx.signed.json
+//	# online: send the signed tx
+//	offline submit -in tx.signed.json
+//
+// The online steps read their config from -config, or from the environment
+// like sdk.ConfigFromEnv. The sign step never touches the network.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/Zecrey-Labs/zecrey-marketplace-go-sdk/sdk"
+	"github.com/Zecrey-Labs/zecrey-marketplace-go-sdk/sdk/model"
+)
+
+const passwordEnv = "ZECREY_KEYSTORE_PASSWORD"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "prepare":
+		err = prepare(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "submit":
+		err = submit(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: offline prepare|sign|submit [flags], see offline <step> -h")
+	os.Exit(2)
+}
+
+func prepare(args []string) error {
+	fs := flag.NewFlagSet("prepare", flag.ExitOnError)
+	var (
+		configFile   = fs.String("config", "", "config file, defaults to the environment")
+		out          = fs.String("out", "", "envelope file to write")
+		op           = fs.String("op", "", "operation: CreateCollection, MintNft, TransferNft, WithdrawNft, CreateSellOffer, CreateBuyOffer or AcceptOffer")
+		account      = fs.String("account", "", "account name of the signer")
+		shortName    = fs.String("short-name", "", "collection short name")
+		categoryId   = fs.String("category-id", "1", "collection category id")
+		earningRate  = fs.String("earning-rate", "0", "collection creator earning rate")
+		collectionId = fs.Int64("collection-id", 0, "collection id of the nft to mint")
+		nftUrl       = fs.String("nft-url", "", "url of the nft to mint")
+		name         = fs.String("name", "", "name of the nft to mint")
+		description  = fs.String("description", "", "description of the collection or nft")
+		media        = fs.String("media", "", "media of the nft to mint")
+		properties   = fs.String("properties", "[]", "properties of the nft to mint")
+		levels       = fs.String("levels", "[]", "levels of the nft to mint")
+		stats        = fs.String("stats", "[]", "stats of the nft to mint")
+		assetId      = fs.Int64("asset-id", 0, "nft id")
+		to           = fs.String("to", "", "account name the nft is transferred to")
+		assetType    = fs.Int64("asset-type", 0, "payment asset id of the offer")
+		amount       = fs.String("amount", "", "payment asset amount of the offer")
+		offerId      = fs.Int64("offer-id", 0, "id of the offer to accept")
+		sell         = fs.Bool("sell", false, "accept as the seller")
+		assembly     = fs.String("assembly", "server", "who assembles the tx: server, local or cross-check")
+		treasuryRate = fs.Int64("treasury-rate", 0, "treasury rate, in basis points, of offers assembled locally, required with -assembly local or cross-check")
+	)
+	fs.Parse(args)
+	if *out == "" || *account == "" {
+		return fmt.Errorf("-out and -account are required")
+	}
+	mode, ok := map[string]sdk.TxAssemblyMode{
+		"server":      sdk.TxAssemblyServer,
+		"local":       sdk.TxAssemblyLocal,
+		"cross-check": sdk.TxAssemblyCrossCheck,
+	}[*assembly]
+	if !ok {
+		return fmt.Errorf("unknown -assembly %q", *assembly)
+	}
+	rateSet := false
+	fs.Visit(func(f *flag.Flag) { rateSet = rateSet || f.Name == "treasury-rate" })
+	isOffer := *op == sdk.OpCreateSellOffer || *op == sdk.OpCreateBuyOffer || *op == sdk.OpAcceptOffer
+	if isOffer && mode != sdk.TxAssemblyServer && !rateSet {
+		return fmt.Errorf("-treasury-rate is required to assemble offers with -assembly %s", *assembly)
+	}
+	err := loadConfig(*configFile, func(cfg *sdk.Config) {
+		cfg.TxAssembly = mode
+		cfg.OfferTreasuryRate = *treasuryRate
+	})
+	if err != nil {
+		return err
+	}
+	var assetAmount *big.Int
+	if isOffer {
+		var ok bool
+		if assetAmount, ok = new(big.Int).SetString(*amount, 10); !ok {
+			return fmt.Errorf("invalid -amount %q", *amount)
+		}
+	}
+
+	var env *sdk.Envelope
+	switch *op {
+	case sdk.OpCreateCollection:
+		env, err = sdk.PrepareCreateCollectionEnvelope(*account, *shortName, *categoryId, *earningRate, model.WithDescription(*description))
+	case sdk.OpMintNft:
+		env, err = sdk.PrepareMintNftEnvelope(*account, *collectionId, *nftUrl, *name, *description, *media, *properties, *levels, *stats)
+	case sdk.OpTransferNft:
+		env, err = sdk.PrepareTransferNftEnvelope(*account, *assetId, *to)
+	case sdk.OpWithdrawNft:
+		env, err = sdk.PrepareWithdrawNftEnvelope(*account, *assetId)
+	case sdk.OpCreateSellOffer, sdk.OpCreateBuyOffer:
+		env, err = sdk.PrepareOfferEnvelope(*account, *assetId, *assetType, assetAmount, *op == sdk.OpCreateSellOffer)
+	case sdk.OpAcceptOffer:
+		env, err = sdk.PrepareAcceptOfferEnvelope(*account, *offerId, *sell, assetAmount)
+	default:
+		return fmt.Errorf("unknown -op %q", *op)
+	}
+	if err != nil {
+		return err
+	}
+	return sdk.WriteEnvelope(*out, env)
+}
+
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	var (
+		in       = fs.String("in", "", "envelope file to sign")
+		out      = fs.String("out", "", "signed envelope file to write, defaults to -in")
+		keystore = fs.String("keystore", "", "keystore of the account, its password is read from "+passwordEnv)
+		seedEnv  = fs.String("seed-env", "", "name of an environment variable holding the seed, instead of -keystore")
+	)
+	fs.Parse(args)
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+	if *out == "" {
+		*out = *in
+	}
+	env, err := sdk.ReadEnvelope(*in)
+	if err != nil {
+		return err
+	}
+	var key sdk.KeyManager
+	switch {
+	case *keystore != "":
+		password := os.Getenv(passwordEnv)
+		if password == "" {
+			return fmt.Errorf("%s is not set", passwordEnv)
+		}
+		ks, err := sdk.NewKeystore(*keystore).Load(password)
+		if err != nil {
+			return err
+		}
+		defer ks.Close()
+		if name := ks.AccountName(); name != "" && name != env.AccountName {
+			return fmt.Errorf("keystore of %s can not sign for %s", name, env.AccountName)
+		}
+		key = ks
+	case *seedEnv != "":
+		seedKey, err := sdk.NewSeedKeyManager(os.Getenv(*seedEnv))
+		if err != nil {
+			// the error may quote the seed, keep it off the terminal
+			return fmt.Errorf("invalid seed in %s", *seedEnv)
+		}
+		defer seedKey.(io.Closer).Close()
+		key = seedKey
+	default:
+		return fmt.Errorf("-keystore or -seed-env is required")
+	}
+	// show what is signed, the prepared tx comes from an online host
+	fmt.Fprintf(os.Stderr, "signing %s of %s, tx type %d: %s\n", env.Operation, env.AccountName, env.TxType, env.TxInfo)
+	if err := sdk.SignEnvelope(key, env); err != nil {
+		return err
+	}
+	return sdk.WriteEnvelope(*out, env)
+}
+
+func submit(args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	var (
+		configFile = fs.String("config", "", "config file, defaults to the environment")
+		in         = fs.String("in", "", "signed envelope file to submit")
+	)
+	fs.Parse(args)
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+	if err := loadConfig(*configFile, nil); err != nil {
+		return err
+	}
+	env, err := sdk.ReadEnvelope(*in)
+	if err != nil {
+		return err
+	}
+	var result json.RawMessage
+	if err := sdk.SubmitEnvelope(env, &result); err != nil {
+		return err
+	}
+	fmt.Println(string(result))
+	return nil
+}
+
+// loadConfig sets the config of file, or of the environment, after configure
+// when not nil.
+func loadConfig(file string, configure func(cfg *sdk.Config)) error {
+	var (
+		cfg *sdk.Config
+		err error
+	)
+	if file != "" {
+		cfg, err = sdk.LoadConfig(file)
+	} else {
+		cfg, err = sdk.ConfigFromEnv()
+	}
+	if err != nil {
+		return err
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	return sdk.SetConfig(cfg)
+}
@@ -0,0 +1,108 @@
+// Command signer is a reference remote signer for sdk.RemoteKeyManager. It
+// holds the L2 keys of one or more accounts and signs over mutual TLS.
+//
+//	signer -listen :8443 -cert server.pem -key server-key.pem -client-ca ca.pem \
+//		-keystore alice=/keys/alice.json -keystore bob=/keys/bob.json
+//
+// The keystore password is read from ZECREY_SIGNER_PASSWORD. A raw seed can
+// be served instead with -seed-env, e.g. -seed-env alice=ALICE_SEED.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Zecrey-Labs/zecrey-marketplace-go-sdk/sdk"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const passwordEnv = "ZECREY_SIGNER_PASSWORD"
+
+type keyFlags map[string]string
+
+func (f keyFlags) String() string { return fmt.Sprint(map[string]string(f)) }
+
+func (f keyFlags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("expected key_id=value, got %q", value)
+	}
+	f[parts[0]] = parts[1]
+	return nil
+}
+
+func main() {
+	var (
+		listen   = flag.String("listen", ":8443", "listen address")
+		certFile = flag.String("cert", "", "server certificate")
+		keyFile  = flag.String("key", "", "server private key")
+		caFile   = flag.String("client-ca", "", "CA of the accepted client certificates")
+		stores   = keyFlags{}
+		seeds    = keyFlags{}
+	)
+	flag.Var(stores, "keystore", "key_id=path of a keystore to serve, repeatable")
+	flag.Var(seeds, "seed-env", "key_id=name of an environment variable holding a seed, repeatable")
+	flag.Parse()
+
+	if *certFile == "" || *keyFile == "" || *caFile == "" {
+		fmt.Fprintln(os.Stderr, "-cert, -key and -client-ca are required")
+		os.Exit(2)
+	}
+	keys, err := loadKeys(stores, seeds)
+	if err != nil {
+		logx.Errorf("load keys: %v", err)
+		os.Exit(1)
+	}
+	tlsConfig, err := sdk.LoadMutualTLSConfig(*certFile, *keyFile, *caFile, true)
+	if err != nil {
+		logx.Errorf("load tls config: %v", err)
+		os.Exit(1)
+	}
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           sdk.NewSignerHandler(keys, sdk.NewLogxLogger(sdk.LevelInfo)),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logx.Infof("signer serving %d keys on %s", len(keys), *listen)
+	if err := server.ListenAndServeTLS("", ""); err != nil {
+		logx.Errorf("serve: %v", err)
+		os.Exit(1)
+	}
+}
+
+func loadKeys(stores, seeds keyFlags) (map[string]sdk.KeyManager, error) {
+	keys := make(map[string]sdk.KeyManager)
+	if len(stores) > 0 {
+		password := os.Getenv(passwordEnv)
+		if password == "" {
+			return nil, fmt.Errorf("%s is not set", passwordEnv)
+		}
+		for id, path := range stores {
+			key, err := sdk.NewKeystore(path).Load(password)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", id, err)
+			}
+			keys[id] = key
+		}
+	}
+	for id, name := range seeds {
+		if _, ok := keys[id]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", id)
+		}
+		key, err := sdk.NewSeedKeyManager(os.Getenv(name))
+		if err != nil {
+			// the error may quote the seed, keep it out of the logs
+			return nil, fmt.Errorf("invalid seed for key id %s", id)
+		}
+		keys[id] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key to serve, use -keystore or -seed-env")
+	}
+	return keys, nil
+}
@@ -0,0 +1,359 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ProfileLocal = "local"
+	ProfileDev   = "dev"
+	ProfileQa    = "qa"
+	ProfileTest  = "test"
+
+	EnvConfigFile     = "ZECREY_CONFIG_FILE"
+	EnvProfile        = "ZECREY_PROFILE"
+	EnvNftMarketUrl   = "ZECREY_NFT_MARKET_URL"
+	EnvLegendUrl      = "ZECREY_LEGEND_URL"
+	EnvHasuraUrl      = "ZECREY_HASURA_URL"
+	EnvHasuraAdminKey = "ZECREY_HASURA_ADMIN_KEY"
+	EnvHasuraKeyFile  = "ZECREY_HASURA_KEY_FILE"
+	EnvHasuraRole     = "ZECREY_HASURA_ROLE"
+	EnvHasuraTimeout  = "ZECREY_HASURA_TIMEOUT"
+	EnvChainRpcUrl    = "ZECREY_CHAIN_RPC_URL"
+)
+
+// Config holds the settings and runtime dependencies of one zecrey environment.
+type Config struct {
+	Settings
+
+	// HTTPClient is shared by every marketplace, legend and hasura call made
+	// with this config. When nil, a client around Transport is used, and when
+	// both are nil the SDK wide default client.
+	HTTPClient *http.Client
+	Transport  http.RoundTripper
+
+	// Retry is the retry policy of reads, prepare-tx calls and tx submissions.
+	// When nil, DefaultRetryPolicy is used; set NoRetry to disable retries.
+	Retry *RetryPolicy
+
+	// RateLimiters throttle the requests of each endpoint family. Families
+	// without a limiter are not limited. Limiters are shared by every copy of
+	// the config, so one map can limit many clients together.
+	RateLimiters map[EndpointFamily]*RateLimiter
+
+	// HealthCheckInterval, when set, probes the endpoints of services with
+	// fallback endpoints in the background, see CheckEndpoints, until Close.
+	HealthCheckInterval time.Duration
+
+	// Logger receives the SDK logs, with secrets redacted. Defaults to NopLogger.
+	Logger Logger
+
+	// HasuraCredentials authenticates hasura requests. When nil, HasuraKeyFile
+	// and then HasuraAdminKey are used, and without any the requests are anonymous.
+	HasuraCredentials CredentialProvider
+
+	// TracerProvider and MeterProvider enable the OpenTelemetry spans and metrics
+	// of every operation and request. Telemetry is off while both are nil.
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+
+	// TxAssembly selects who assembles the txs signed by the write operations,
+	// the marketplace preparetx endpoints by default.
+	TxAssembly TxAssemblyMode
+	// OfferTreasuryRate is the marketplace treasury rate, in basis points, of
+	// the offers assembled locally. It is required to assemble offers locally,
+	// as the marketplace rejects offers signed with another rate.
+	OfferTreasuryRate int64
+
+	// GasFee selects the asset paying the gas fee of the txs and caps the fee,
+	// WithGasFeePolicy overrides it per operation. When nil, the default fee
+	// asset pays without cap.
+	GasFee *GasFeePolicy
+
+	// pools are the endpoint pools, shared by every copy of the config
+	pools *endpointPools
+}
+
+// Settings is the part of Config that can be loaded from a file or the environment.
+// Empty fields are filled from Profile (or the default config) when loaded.
+type Settings struct {
+	Profile        string `json:"profile,optional"`
+	NftMarketUrl   string `json:"nft_market_url,optional"`
+	LegendUrl      string `json:"legend_url,optional"`
+	HasuraUrl      string `json:"hasura_url,optional"`
+	HasuraAdminKey string `json:"hasura_admin_key,optional"` // prefer hasura_key_file or HasuraCredentials
+	HasuraKeyFile  string `json:"hasura_key_file,optional"`
+	HasuraRole     string `json:"hasura_role,optional"`
+	HasuraTimeout  int64  `json:"hasura_timeout,optional"` // seconds
+	ChainRpcUrl    string `json:"chain_rpc_url,optional"`
+
+	// Fallback endpoints, tried in order when the one above is unreachable.
+	NftMarketUrls []string `json:"nft_market_urls,optional"`
+	LegendUrls    []string `json:"legend_urls,optional"`
+	ChainRpcUrls  []string `json:"chain_rpc_urls,optional"`
+}
+
+var (
+	profiles = map[string]Config{
+		ProfileLocal: {
+			Settings: Settings{
+				Profile:       ProfileLocal,
+				NftMarketUrl:  "http://localhost:9999",
+				LegendUrl:     "https://qa-legend-app.zecrey.com",
+				HasuraUrl:     "https://legend-market-qa.hasura.app/v1/graphql",
+				HasuraTimeout: hasuraTimeDeadline,
+				ChainRpcUrl:   "https://data-seed-prebsc-1-s1.binance.org:8545",
+			},
+		},
+		ProfileDev: {
+			Settings: Settings{
+				Profile:       ProfileDev,
+				NftMarketUrl:  "https://dev-legend-nft.zecrey.com",
+				LegendUrl:     "https://dev-legend-app.zecrey.com",
+				HasuraUrl:     "https://legend-market-dev.hasura.app/v1/graphql",
+				HasuraTimeout: hasuraTimeDeadline,
+				ChainRpcUrl:   "https://data-seed-prebsc-1-s1.binance.org:8545",
+			},
+		},
+		ProfileQa: {
+			Settings: Settings{
+				Profile:       ProfileQa,
+				NftMarketUrl:  "https://qa-legend-nft.zecrey.com",
+				LegendUrl:     "https://qa-legend-app.zecrey.com",
+				HasuraUrl:     "https://legend-market-qa.hasura.app/v1/graphql",
+				HasuraTimeout: hasuraTimeDeadline,
+				ChainRpcUrl:   "https://data-seed-prebsc-1-s1.binance.org:8545",
+			},
+		},
+		ProfileTest: {
+			Settings: Settings{
+				Profile:       ProfileTest,
+				NftMarketUrl:  "https://test-legend-nft.zecrey.com",
+				LegendUrl:     "https://test-legend-app.zecrey.com",
+				HasuraUrl:     "https://legend-marketplace.hasura.app/v1/graphql",
+				HasuraTimeout: hasuraTimeDeadline,
+				ChainRpcUrl:   "https://data-seed-prebsc-1-s1.binance.org:8545",
+			},
+		},
+	}
+	profilesLock sync.RWMutex
+
+	currentConfig     = DefaultConfig()
+	currentConfigLock sync.RWMutex
+)
+
+// DefaultConfig returns the endpoints the SDK has historically shipped with:
+// the test marketplace backed by the qa legend and hasura services.
+func DefaultConfig() *Config {
+	return &Config{
+		Settings: Settings{
+			NftMarketUrl:  "https://test-legend-nft.zecrey.com",
+			LegendUrl:     "https://qa-legend-app.zecrey.com",
+			HasuraUrl:     "https://legend-market-qa.hasura.app/v1/graphql",
+			HasuraTimeout: hasuraTimeDeadline,
+			ChainRpcUrl:   "https://data-seed-prebsc-1-s1.binance.org:8545",
+		},
+	}
+}
+
+// RegisterProfile adds or replaces a named profile. The SDK ships no mainnet
+// profile; register one with the production endpoints, e.g. as "mainnet".
+func RegisterProfile(name string, cfg Config) {
+	profilesLock.Lock()
+	defer profilesLock.Unlock()
+	cfg.Profile = name
+	profiles[name] = cfg
+}
+
+// ConfigForProfile returns a copy of the named profile.
+func ConfigForProfile(name string) (*Config, error) {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+	cfg, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile: %s", name)
+	}
+	return &cfg, nil
+}
+
+// LoadConfig loads a config from a .json, .yaml or .yml file.
+// ${VAR} references in the file are expanded from the environment.
+func LoadConfig(file string) (*Config, error) {
+	loaded := &Settings{}
+	if err := conf.LoadConfig(file, loaded, conf.UseEnv()); err != nil {
+		return nil, err
+	}
+	cfg, err := baseConfig(loaded.Profile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.merge(loaded)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ConfigFromEnv builds a config from ZECREY_CONFIG_FILE or ZECREY_PROFILE,
+// then applies the per-field ZECREY_* overrides.
+func ConfigFromEnv() (*Config, error) {
+	var (
+		cfg *Config
+		err error
+	)
+	if file := os.Getenv(EnvConfigFile); file != "" {
+		cfg, err = LoadConfig(file)
+	} else {
+		cfg, err = baseConfig(os.Getenv(EnvProfile))
+	}
+	if err != nil {
+		return nil, err
+	}
+	override := &Settings{
+		HasuraUrl:      os.Getenv(EnvHasuraUrl),
+		HasuraAdminKey: os.Getenv(EnvHasuraAdminKey),
+		HasuraKeyFile:  os.Getenv(EnvHasuraKeyFile),
+		HasuraRole:     os.Getenv(EnvHasuraRole),
+	}
+	// the endpoint variables accept comma separated fallback endpoints
+	override.NftMarketUrl, override.NftMarketUrls = splitEndpoints(os.Getenv(EnvNftMarketUrl))
+	override.LegendUrl, override.LegendUrls = splitEndpoints(os.Getenv(EnvLegendUrl))
+	override.ChainRpcUrl, override.ChainRpcUrls = splitEndpoints(os.Getenv(EnvChainRpcUrl))
+	if timeout := os.Getenv(EnvHasuraTimeout); timeout != "" {
+		override.HasuraTimeout, err = strconv.ParseInt(timeout, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", EnvHasuraTimeout, timeout)
+		}
+	}
+	cfg.merge(override)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// SetConfig sets the config used by the package level functions
+// and by NewZecreyMarketplaceClient.
+func SetConfig(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	// create the pools first so that the copy shares them
+	cfg.endpointPools()
+	c := *cfg
+	currentConfigLock.Lock()
+	defer currentConfigLock.Unlock()
+	currentConfig = &c
+	return nil
+}
+
+// GetConfig returns a copy of the config used by the package level functions.
+// Changes to the copy take effect only once passed to SetConfig.
+func GetConfig() *Config {
+	currentConfigLock.RLock()
+	defer currentConfigLock.RUnlock()
+	c := *currentConfig
+	return &c
+}
+
+func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("nil config")
+	}
+	if cfg.NftMarketUrl == "" {
+		return fmt.Errorf("config: nft_market_url is required")
+	}
+	if cfg.LegendUrl == "" {
+		return fmt.Errorf("config: legend_url is required")
+	}
+	if cfg.HasuraUrl == "" {
+		return fmt.Errorf("config: hasura_url is required")
+	}
+	if cfg.ChainRpcUrl == "" {
+		return fmt.Errorf("config: chain_rpc_url is required")
+	}
+	if cfg.HasuraTimeout < 0 {
+		return fmt.Errorf("config: hasura_timeout must not be negative")
+	}
+	if cfg.TxAssembly < TxAssemblyServer || cfg.TxAssembly > TxAssemblyCrossCheck {
+		return fmt.Errorf("config: unknown tx assembly mode %d", cfg.TxAssembly)
+	}
+	if cfg.OfferTreasuryRate < 0 || cfg.OfferTreasuryRate > 10000 {
+		return fmt.Errorf("config: offer treasury rate must be between 0 and 10000")
+	}
+	return nil
+}
+
+// merge sets the non empty fields of other. Setting an endpoint replaces its
+// fallbacks as well, so that they never point at another network.
+func (cfg *Config) merge(other *Settings) {
+	if other.NftMarketUrl != "" {
+		cfg.NftMarketUrl, cfg.NftMarketUrls = other.NftMarketUrl, other.NftMarketUrls
+	}
+	if other.LegendUrl != "" {
+		cfg.LegendUrl, cfg.LegendUrls = other.LegendUrl, other.LegendUrls
+	}
+	if other.HasuraUrl != "" {
+		cfg.HasuraUrl = other.HasuraUrl
+	}
+	if other.HasuraAdminKey != "" {
+		cfg.HasuraAdminKey = other.HasuraAdminKey
+	}
+	if other.HasuraKeyFile != "" {
+		cfg.HasuraKeyFile = other.HasuraKeyFile
+	}
+	if other.HasuraRole != "" {
+		cfg.HasuraRole = other.HasuraRole
+	}
+	if other.HasuraTimeout != 0 {
+		cfg.HasuraTimeout = other.HasuraTimeout
+	}
+	if other.ChainRpcUrl != "" {
+		cfg.ChainRpcUrl, cfg.ChainRpcUrls = other.ChainRpcUrl, other.ChainRpcUrls
+	}
+	if len(other.NftMarketUrls) > 0 {
+		cfg.NftMarketUrls = other.NftMarketUrls
+	}
+	if len(other.LegendUrls) > 0 {
+		cfg.LegendUrls = other.LegendUrls
+	}
+	if len(other.ChainRpcUrls) > 0 {
+		cfg.ChainRpcUrls = other.ChainRpcUrls
+	}
+}
+
+// httpClient returns the client every HTTP call made with cfg goes through.
+func (cfg *Config) httpClient() *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
+	}
+	if cfg.Transport != nil {
+		return &http.Client{Transport: cfg.Transport}
+	}
+	return defaultHTTPClient
+}
+
+func splitEndpoints(value string) (primary string, fallbacks []string) {
+	urls := dedupe(strings.Split(value, ","))
+	if len(urls) == 0 {
+		return "", nil
+	}
+	return urls[0], urls[1:]
+}
+
+func baseConfig(profile string) (*Config, error) {
+	if profile == "" {
+		return DefaultConfig(), nil
+	}
+	return ConfigForProfile(profile)
+}
@@ -0,0 +1,121 @@
+package sdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigForProfile(t *testing.T) {
+	cfg, err := ConfigForProfile(ProfileQa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NftMarketUrl != "https://qa-legend-nft.zecrey.com" {
+		t.Fatalf("unexpected nft market url: %s", cfg.NftMarketUrl)
+	}
+	if _, err := ConfigForProfile("unknown"); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+
+	registered := *cfg
+	registered.NftMarketUrl = "https://nft.example.com"
+	RegisterProfile("mainnet", registered)
+	defer func() {
+		profilesLock.Lock()
+		defer profilesLock.Unlock()
+		delete(profiles, "mainnet")
+	}()
+	mainnet, err := ConfigForProfile("mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mainnet.Profile != "mainnet" || mainnet.NftMarketUrl != "https://nft.example.com" {
+		t.Fatalf("unexpected registered profile %+v", mainnet.Settings)
+	}
+}
+
+func TestGetConfigCopy(t *testing.T) {
+	old := GetConfig()
+	defer SetConfig(old)
+	cfg := GetConfig()
+	cfg.NftMarketUrl = ""
+	if GetConfig().NftMarketUrl == "" {
+		t.Fatal("change to the returned config bypassed SetConfig")
+	}
+	if err := SetConfig(cfg); err == nil {
+		t.Fatal("invalid config set")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zecrey-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "mainnet.json")
+	content := `{"profile": "qa", "nft_market_url": "https://nft.example.com", "hasura_admin_key": "${TEST_HASURA_KEY}"}`
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_HASURA_KEY", "secret")
+	defer os.Unsetenv("TEST_HASURA_KEY")
+
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NftMarketUrl != "https://nft.example.com" {
+		t.Fatalf("unexpected nft market url: %s", cfg.NftMarketUrl)
+	}
+	if cfg.LegendUrl != "https://qa-legend-app.zecrey.com" {
+		t.Fatalf("legend url not taken from profile: %s", cfg.LegendUrl)
+	}
+	if cfg.HasuraAdminKey != "secret" {
+		t.Fatalf("hasura key not expanded: %s", cfg.HasuraAdminKey)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	os.Setenv(EnvProfile, ProfileDev)
+	os.Setenv(EnvChainRpcUrl, "https://rpc.example.com")
+	defer os.Unsetenv(EnvProfile)
+	defer os.Unsetenv(EnvChainRpcUrl)
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LegendUrl != "https://dev-legend-app.zecrey.com" {
+		t.Fatalf("unexpected legend url: %s", cfg.LegendUrl)
+	}
+	if cfg.ChainRpcUrl != "https://rpc.example.com" {
+		t.Fatalf("unexpected chain rpc url: %s", cfg.ChainRpcUrl)
+	}
+
+	// an endpoint set alone drops the fallbacks of the file
+	dir, err := ioutil.TempDir("", "zecrey-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.json")
+	content := `{"profile": "qa", "legend_urls": ["https://legend-2.example.com"], "chain_rpc_urls": ["https://rpc-2.example.com"]}`
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(EnvConfigFile, file)
+	defer os.Unsetenv(EnvConfigFile)
+	cfg, err = ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.ChainRpcUrls) != 0 {
+		t.Fatalf("fallbacks of another endpoint kept: %v", cfg.ChainRpcUrls)
+	}
+	if len(cfg.LegendUrls) != 1 {
+		t.Fatalf("fallbacks of an endpoint not set lost: %v", cfg.LegendUrls)
+	}
+}
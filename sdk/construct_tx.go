@@ -2,131 +2,210 @@ package sdk
 
 import (
 	"encoding/json"
+	"errors"
+	"hash"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
 	"github.com/zecrey-labs/zecrey-crypto/wasm/zecrey-legend/legendTxTypes"
 )
 
-func ConstructCreateCollectionTx(key KeyManager, tx *CreateCollectionTxInfo) (string, error) {
-	convertedTx := ConvertCreateCollectionTxInfo(tx)
+// SignableTx is an L2 tx the SDK signs. Adding a tx type only takes
+// implementing it; ConstructTx, the key managers and VerifyTx handle the rest.
+type SignableTx interface {
+	// TxType returns the TxType* constant of the tx.
+	TxType() int
+	// MsgHash computes the message hash to sign with hFunc.
+	MsgHash(hFunc hash.Hash) ([]byte, error)
+	// SignedTx returns the legendTxTypes form of the tx carrying sig, as submitted.
+	SignedTx(sig []byte) interface{}
+	// Signature returns the signature of a parsed signed tx.
+	Signature() []byte
+}
+
+// ConstructTx signs tx with key and returns the signed tx info to submit.
+func ConstructTx(key KeyManager, tx SignableTx) (string, error) {
+	if tx, ok := tx.(feeTx); ok && tx.gasFee().Amount == nil {
+		return "", errors.New("gas fee is not set")
+	}
 	hFunc := mimc.NewMiMC()
-	msgHash, err := legendTxTypes.ComputeCreateCollectionMsgHash(convertedTx, hFunc)
+	msgHash, err := tx.MsgHash(hFunc)
 	if err != nil {
 		return "", err
 	}
 	hFunc.Reset()
-	signature, err := key.Sign(msgHash, hFunc)
+	signature, err := signTx(key, tx, msgHash, hFunc)
 	if err != nil {
 		return "", err
 	}
-	convertedTx.Sig = signature
-	txInfoBytes, err := json.Marshal(convertedTx)
+	txInfoBytes, err := json.Marshal(tx.SignedTx(signature))
 	if err != nil {
 		return "", err
 	}
 	return string(txInfoBytes), nil
 }
 
+func ConstructCreateCollectionTx(key KeyManager, tx *CreateCollectionTxInfo) (string, error) {
+	return ConstructTx(key, tx)
+}
+
 func ConstructTransferNftTx(key KeyManager, tx *TransferNftTxInfo) (string, error) {
-	convertedTx := ConvertTransferNftTxInfo(tx)
-	hFunc := mimc.NewMiMC()
-	msgHash, err := legendTxTypes.ComputeTransferNftMsgHash(convertedTx, hFunc)
-	if err != nil {
-		return "", err
-	}
-	hFunc.Reset()
-	signature, err := key.Sign(msgHash, hFunc)
-	if err != nil {
-		return "", err
-	}
-	convertedTx.Sig = signature
-	txInfoBytes, err := json.Marshal(convertedTx)
-	if err != nil {
-		return "", err
-	}
-	return string(txInfoBytes), nil
+	return ConstructTx(key, tx)
 }
 
 func ConstructWithdrawNftTx(key KeyManager, tx *WithdrawNftTxInfo) (string, error) {
-	convertedTx := ConvertWithdrawNftTxInfo(tx)
-	hFunc := mimc.NewMiMC()
-	msgHash, err := legendTxTypes.ComputeWithdrawNftMsgHash(convertedTx, hFunc)
-	if err != nil {
-		return "", err
-	}
-	hFunc.Reset()
-	signature, err := key.Sign(msgHash, hFunc)
-	if err != nil {
-		return "", err
-	}
-	convertedTx.Sig = signature
-	txInfoBytes, err := json.Marshal(convertedTx)
-	if err != nil {
-		return "", err
-	}
-	return string(txInfoBytes), nil
+	return ConstructTx(key, tx)
 }
 
 func ConstructOfferTx(key KeyManager, tx *OfferTxInfo) (string, error) {
-	convertedTx := ConvertOfferTxInfo(tx)
-	hFunc := mimc.NewMiMC()
-	msgHash, err := legendTxTypes.ComputeOfferMsgHash(convertedTx, hFunc)
-	if err != nil {
-		return "", err
-	}
-	hFunc.Reset()
-	signature, err := key.Sign(msgHash, hFunc)
-	if err != nil {
-		return "", err
-	}
-	convertedTx.Sig = signature
-	txInfoBytes, err := json.Marshal(convertedTx)
-	if err != nil {
-		return "", err
-	}
-	return string(txInfoBytes), nil
+	return ConstructTx(key, tx)
 }
 
 func ConstructMintNftTx(key KeyManager, tx *MintNftTxInfo) (string, error) {
-	convertedTx := ConvertMintNftTxInfo(tx)
-	hFunc := mimc.NewMiMC()
-	msgHash, err := legendTxTypes.ComputeMintNftMsgHash(convertedTx, hFunc)
-	if err != nil {
-		return "", err
-	}
-	hFunc.Reset()
-	signature, err := key.Sign(msgHash, hFunc)
-	if err != nil {
-		return "", err
-	}
-	convertedTx.Sig = signature
-	txInfoBytes, err := json.Marshal(convertedTx)
-	if err != nil {
-		return "", err
-	}
-	return string(txInfoBytes), nil
+	return ConstructTx(key, tx)
 }
 
 func ConstructAtomicMatchTx(key KeyManager, tx *AtomicMatchTxInfo) (string, error) {
+	return ConstructTx(key, tx)
+}
+
+func ConstructTransferTx(key KeyManager, tx *TransferTxInfo) (string, error) {
+	return ConstructTx(key, tx)
+}
+
+func ConstructWithdrawTx(key KeyManager, tx *WithdrawTxInfo) (string, error) {
+	return ConstructTx(key, tx)
+}
+
+func ConstructCancelOfferTx(key KeyManager, tx *CancelOfferTxInfo) (string, error) {
+	return ConstructTx(key, tx)
+}
+
+func (tx *CreateCollectionTxInfo) TxType() int { return TxTypeCreateCollection }
+
+func (tx *CreateCollectionTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeCreateCollectionMsgHash(ConvertCreateCollectionTxInfo(tx), hFunc)
+}
+
+func (tx *CreateCollectionTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertCreateCollectionTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *CreateCollectionTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *MintNftTxInfo) TxType() int { return TxTypeMintNft }
+
+func (tx *MintNftTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeMintNftMsgHash(ConvertMintNftTxInfo(tx), hFunc)
+}
+
+func (tx *MintNftTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertMintNftTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *MintNftTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *TransferNftTxInfo) TxType() int { return TxTypeTransferNft }
+
+func (tx *TransferNftTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeTransferNftMsgHash(ConvertTransferNftTxInfo(tx), hFunc)
+}
+
+func (tx *TransferNftTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertTransferNftTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *TransferNftTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *AtomicMatchTxInfo) TxType() int { return TxTypeAtomicMatch }
+
+func (tx *AtomicMatchTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeAtomicMatchMsgHash(ConvertAtomicMatchTxInfo(tx), hFunc)
+}
+
+func (tx *AtomicMatchTxInfo) SignedTx(sig []byte) interface{} {
 	convertedTx := ConvertAtomicMatchTxInfo(tx)
-	hFunc := mimc.NewMiMC()
-	msgHash, err := legendTxTypes.ComputeAtomicMatchMsgHash(convertedTx, hFunc)
-	if err != nil {
-		return "", err
-	}
-	hFunc.Reset()
-	signature, err := key.Sign(msgHash, hFunc)
-	if err != nil {
-		return "", err
-	}
-	convertedTx.Sig = signature
-	txInfoBytes, err := json.Marshal(convertedTx)
-	if err != nil {
-		return "", err
-	}
-	return string(txInfoBytes), nil
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *AtomicMatchTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *WithdrawNftTxInfo) TxType() int { return TxTypeWithdrawNft }
+
+func (tx *WithdrawNftTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeWithdrawNftMsgHash(ConvertWithdrawNftTxInfo(tx), hFunc)
 }
 
+func (tx *WithdrawNftTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertWithdrawNftTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *WithdrawNftTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *OfferTxInfo) TxType() int { return TxTypeOffer }
+
+func (tx *OfferTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeOfferMsgHash(ConvertOfferTxInfo(tx), hFunc)
+}
+
+func (tx *OfferTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertOfferTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *OfferTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *TransferTxInfo) TxType() int { return TxTypeTransfer }
+
+func (tx *TransferTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeTransferMsgHash(ConvertTransferTxInfo(tx), hFunc)
+}
+
+func (tx *TransferTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertTransferTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *TransferTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *WithdrawTxInfo) TxType() int { return TxTypeWithdraw }
+
+func (tx *WithdrawTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeWithdrawMsgHash(ConvertWithdrawTxInfo(tx), hFunc)
+}
+
+func (tx *WithdrawTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertWithdrawTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *WithdrawTxInfo) Signature() []byte { return tx.Sig }
+
+func (tx *CancelOfferTxInfo) TxType() int { return TxTypeCancelOffer }
+
+func (tx *CancelOfferTxInfo) MsgHash(hFunc hash.Hash) ([]byte, error) {
+	return legendTxTypes.ComputeCancelOfferMsgHash(ConvertCancelOfferTxInfo(tx), hFunc)
+}
+
+func (tx *CancelOfferTxInfo) SignedTx(sig []byte) interface{} {
+	convertedTx := ConvertCancelOfferTxInfo(tx)
+	convertedTx.Sig = sig
+	return convertedTx
+}
+
+func (tx *CancelOfferTxInfo) Signature() []byte { return tx.Sig }
+
 func ConvertTransferNftTxInfo(tx *TransferNftTxInfo) *legendTxTypes.TransferNftTxInfo {
 	return &legendTxTypes.TransferNftTxInfo{
 		FromAccountIndex:  tx.FromAccountIndex,
@@ -250,3 +329,50 @@ func ConvertAtomicMatchTxInfo(tx *AtomicMatchTxInfo) *legendTxTypes.AtomicMatchT
 		Sig:               tx.Sig,
 	}
 }
+
+func ConvertTransferTxInfo(tx *TransferTxInfo) *legendTxTypes.TransferTxInfo {
+	return &legendTxTypes.TransferTxInfo{
+		FromAccountIndex:  tx.FromAccountIndex,
+		ToAccountIndex:    tx.ToAccountIndex,
+		ToAccountNameHash: tx.ToAccountNameHash,
+		AssetId:           tx.AssetId,
+		AssetAmount:       tx.AssetAmount,
+		GasAccountIndex:   tx.GasAccountIndex,
+		GasFeeAssetId:     tx.GasFeeAssetId,
+		GasFeeAssetAmount: tx.GasFeeAssetAmount,
+		Memo:              tx.Memo,
+		CallData:          tx.CallData,
+		CallDataHash:      tx.CallDataHash,
+		ExpiredAt:         tx.ExpiredAt,
+		Nonce:             tx.Nonce,
+		Sig:               tx.Sig,
+	}
+}
+
+func ConvertWithdrawTxInfo(tx *WithdrawTxInfo) *legendTxTypes.WithdrawTxInfo {
+	return &legendTxTypes.WithdrawTxInfo{
+		FromAccountIndex:  tx.FromAccountIndex,
+		AssetId:           tx.AssetId,
+		AssetAmount:       tx.AssetAmount,
+		GasAccountIndex:   tx.GasAccountIndex,
+		GasFeeAssetId:     tx.GasFeeAssetId,
+		GasFeeAssetAmount: tx.GasFeeAssetAmount,
+		ToAddress:         tx.ToAddress,
+		ExpiredAt:         tx.ExpiredAt,
+		Nonce:             tx.Nonce,
+		Sig:               tx.Sig,
+	}
+}
+
+func ConvertCancelOfferTxInfo(tx *CancelOfferTxInfo) *legendTxTypes.CancelOfferTxInfo {
+	return &legendTxTypes.CancelOfferTxInfo{
+		AccountIndex:      tx.AccountIndex,
+		OfferId:           tx.OfferId,
+		GasAccountIndex:   tx.GasAccountIndex,
+		GasFeeAssetId:     tx.GasFeeAssetId,
+		GasFeeAssetAmount: tx.GasFeeAssetAmount,
+		ExpiredAt:         tx.ExpiredAt,
+		Nonce:             tx.Nonce,
+		Sig:               tx.Sig,
+	}
+}
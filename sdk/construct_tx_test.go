@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConstructTx(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountPk := l2pkOfKey(key)
+	fee := big.NewInt(1000)
+	txs := []SignableTx{
+		&CreateCollectionTxInfo{AccountIndex: 1, Name: "c", GasFeeAssetAmount: fee},
+		&MintNftTxInfo{CreatorAccountIndex: 1, ToAccountIndex: 1, GasFeeAssetAmount: fee},
+		&TransferNftTxInfo{FromAccountIndex: 1, ToAccountIndex: 2, GasFeeAssetAmount: fee},
+		&WithdrawNftTxInfo{AccountIndex: 1, ToAddress: "0x01", GasFeeAssetAmount: fee},
+		&OfferTxInfo{AccountIndex: 1, AssetAmount: big.NewInt(5)},
+		&AtomicMatchTxInfo{AccountIndex: 1, BuyOffer: &OfferTxInfo{AssetAmount: big.NewInt(5)}, SellOffer: &OfferTxInfo{Type: 1, AssetAmount: big.NewInt(5)}, GasFeeAssetAmount: fee},
+		&TransferTxInfo{FromAccountIndex: 1, ToAccountIndex: 2, AssetId: 0, AssetAmount: big.NewInt(7), Memo: "rent", GasFeeAssetAmount: fee},
+		&WithdrawTxInfo{FromAccountIndex: 1, AssetId: 0, AssetAmount: big.NewInt(7), ToAddress: "0x01", GasFeeAssetAmount: fee},
+		&CancelOfferTxInfo{AccountIndex: 1, OfferId: 4, GasFeeAssetAmount: fee},
+	}
+	for _, tx := range txs {
+		signed, err := ConstructTx(key, tx)
+		if err != nil {
+			t.Fatalf("%T: %v", tx, err)
+		}
+		if tx.Signature() != nil {
+			t.Fatalf("%T: tx modified while signing", tx)
+		}
+		parsed := reflect.New(reflect.TypeOf(tx).Elem()).Interface().(SignableTx)
+		if err := json.Unmarshal([]byte(signed), parsed); err != nil {
+			t.Fatalf("%T: %v", tx, err)
+		}
+		if parsed.TxType() != tx.TxType() {
+			t.Fatalf("%T: unexpected tx type %d", tx, parsed.TxType())
+		}
+		if err := VerifyTx(accountPk, parsed); err != nil {
+			t.Fatalf("%T: %v", tx, err)
+		}
+	}
+
+	km := NewPolicyKeyManager(key, SigningPolicy{
+		AllowedTransferAccounts:    []int64{2},
+		ForbiddenWithdrawAddresses: []string{"0x02"},
+	})
+	if _, err := ConstructTransferTx(km, &TransferTxInfo{ToAccountIndex: 3, AssetAmount: big.NewInt(1), GasFeeAssetAmount: fee}); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("transfer to another account signed: %v", err)
+	}
+	if _, err := ConstructWithdrawTx(km, &WithdrawTxInfo{ToAddress: "0x02", AssetAmount: big.NewInt(1), GasFeeAssetAmount: fee}); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("withdraw to a forbidden address signed: %v", err)
+	}
+}
+
+func TestConstructTxCancelOffer(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offer, err := ConstructOfferTx(key, &OfferTxInfo{OfferId: 4, AccountIndex: 1, NftIndex: 7, AssetId: 2, AssetAmount: big.NewInt(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var submitted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/offer/getOfferByOfferId":
+			json.NewEncoder(w).Encode(&RespGetOfferByOfferId{Offer: Offer{Id: 11, PaymentAssetId: 2, Signature: offer}})
+		case "/api/v1/account/getAccountByAccountName":
+			w.Write([]byte(`{"account": {"account_index": 1}}`))
+		case "/api/v1/info/getGasAccount":
+			w.Write([]byte(`{"account_index": 9}`))
+		case "/api/v1/info/getGasFeeAssetList":
+			w.Write([]byte(`{"assets": [{"asset_id": 0}, {"asset_id": 2}]}`))
+		case "/api/v1/info/getGasFee":
+			w.Write([]byte(`{"gas_fee": "5"}`))
+		case "/api/v1/tx/getNextNonce":
+			w.Write([]byte(`{"nonce": 3}`))
+		case "/api/v1/offer/cancelOffer":
+			submitted = r.FormValue("transaction")
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	cfg.LegendUrl = server.URL
+	c := newClientWithKeyManager(cfg, nil, "alice", key, l2pkOfKey(key))
+	if _, err := c.CancelOffer(11); err != nil {
+		t.Fatal(err)
+	}
+	tx := &CancelOfferTxInfo{}
+	if err := json.Unmarshal([]byte(submitted), tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.AccountIndex != 1 || tx.OfferId != 4 || tx.Nonce != 3 || tx.GasFeeAssetId != 2 || tx.GasFeeAssetAmount.Int64() != 5 {
+		t.Fatalf("unexpected cancel offer tx %+v", tx)
+	}
+	if err := VerifyCancelOfferTx(l2pkOfKey(key), tx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,194 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HasuraAccessKeyHeader carries hasura admin secrets and access keys.
+	HasuraAccessKeyHeader = "x-hasura-access-key"
+	HasuraRoleHeader      = "x-hasura-role"
+	AuthorizationHeader   = "Authorization"
+)
+
+// Credential is attached to the requests of one service.
+type Credential struct {
+	Header string // defaults to HasuraAccessKeyHeader
+	Value  string
+	// Role is the hasura role requested with the credential, e.g. "user".
+	// Empty lets the server pick the default role of the credential.
+	Role string
+	// ExpiresAt is used by RefreshableCredentials, zero means no expiry.
+	ExpiresAt time.Time
+}
+
+// CredentialProvider hands out the credential of the next request.
+// Implementations must be safe for concurrent use.
+type CredentialProvider interface {
+	Credential(ctx context.Context) (*Credential, error)
+}
+
+// CredentialFunc adapts a function to CredentialProvider.
+type CredentialFunc func(ctx context.Context) (*Credential, error)
+
+func (f CredentialFunc) Credential(ctx context.Context) (*Credential, error) {
+	return f(ctx)
+}
+
+// StaticCredentials always returns the same key.
+func StaticCredentials(header, value, role string) CredentialProvider {
+	cred := &Credential{Header: header, Value: value, Role: role}
+	return CredentialFunc(func(context.Context) (*Credential, error) {
+		c := *cred
+		return &c, nil
+	})
+}
+
+// BearerToken returns a static credential sending "Authorization: Bearer token",
+// e.g. a hasura JWT.
+func BearerToken(token, role string) CredentialProvider {
+	return StaticCredentials(AuthorizationHeader, "Bearer "+token, role)
+}
+
+// EnvCredentials reads the key from the environment variable name on every
+// request, so that it can be rotated without restarting.
+func EnvCredentials(name, header, role string) CredentialProvider {
+	return CredentialFunc(func(context.Context) (*Credential, error) {
+		value := os.Getenv(name)
+		if value == "" {
+			return nil, fmt.Errorf("credentials: %s is not set", name)
+		}
+		return &Credential{Header: header, Value: value, Role: role}, nil
+	})
+}
+
+// FileCredentials reads the key from file, e.g. a mounted kubernetes secret,
+// and reloads it whenever the file changes.
+func FileCredentials(file, header, role string) CredentialProvider {
+	return &fileCredentials{file: file, header: header, role: role}
+}
+
+type fileCredentials struct {
+	file   string
+	header string
+	role   string
+
+	lock    sync.Mutex
+	modTime time.Time
+	value   string
+}
+
+func (f *fileCredentials) Credential(context.Context) (*Credential, error) {
+	info, err := os.Stat(f.file)
+	if err != nil {
+		return nil, fmt.Errorf("credentials: %v", err)
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.value == "" || !info.ModTime().Equal(f.modTime) {
+		content, err := ioutil.ReadFile(f.file)
+		if err != nil {
+			return nil, fmt.Errorf("credentials: %v", err)
+		}
+		value := strings.TrimSpace(string(content))
+		if value == "" {
+			return nil, fmt.Errorf("credentials: %s is empty", f.file)
+		}
+		f.value, f.modTime = value, info.ModTime()
+	}
+	return &Credential{Header: f.header, Value: f.value, Role: f.role}, nil
+}
+
+// RefreshableCredentials caches the credential returned by fetch, e.g. a short
+// lived token, and fetches a new one once it is within margin of ExpiresAt.
+func RefreshableCredentials(fetch func(ctx context.Context) (*Credential, error), margin time.Duration) CredentialProvider {
+	return &refreshableCredentials{fetch: fetch, margin: margin}
+}
+
+type refreshableCredentials struct {
+	fetch  func(ctx context.Context) (*Credential, error)
+	margin time.Duration
+
+	lock    sync.Mutex
+	current *Credential
+}
+
+func (r *refreshableCredentials) Credential(ctx context.Context) (*Credential, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.current != nil && (r.current.ExpiresAt.IsZero() || time.Until(r.current.ExpiresAt) > r.margin) {
+		c := *r.current
+		return &c, nil
+	}
+	cred, err := r.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("credentials: refresh: %v", err)
+	}
+	if cred == nil || cred.Value == "" {
+		return nil, fmt.Errorf("credentials: refresh returned no credential")
+	}
+	r.current = cred
+	c := *cred
+	return &c, nil
+}
+
+// hasuraCredentials returns the provider of hasura requests, nil for anonymous access.
+func (cfg *Config) hasuraCredentials() CredentialProvider {
+	switch {
+	case cfg.HasuraCredentials != nil:
+		return cfg.HasuraCredentials
+	case cfg.HasuraKeyFile != "":
+		return FileCredentials(cfg.HasuraKeyFile, HasuraAccessKeyHeader, cfg.HasuraRole)
+	case cfg.HasuraAdminKey != "":
+		return StaticCredentials(HasuraAccessKeyHeader, cfg.HasuraAdminKey, cfg.HasuraRole)
+	}
+	return nil
+}
+
+// hasuraCredential returns the credential of the next hasura request, nil for anonymous access.
+func (cfg *Config) hasuraCredential(ctx context.Context) (*Credential, error) {
+	provider := cfg.hasuraCredentials()
+	if provider == nil {
+		if cfg.HasuraRole != "" {
+			return &Credential{Role: cfg.HasuraRole}, nil
+		}
+		return nil, nil
+	}
+	cred, err := provider.Credential(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cred.Header == "" {
+		cred.Header = HasuraAccessKeyHeader
+	}
+	if cred.Role == "" {
+		cred.Role = cfg.HasuraRole
+	}
+	return cred, nil
+}
+
+// apply sets the credential headers on header.
+func (cred *Credential) apply(header http.Header) {
+	if cred == nil {
+		return
+	}
+	if cred.Value != "" {
+		header.Set(cred.Header, cred.Value)
+	}
+	if cred.Role != "" {
+		header.Set(HasuraRoleHeader, cred.Role)
+	}
+}
+
+// sessionVariables are sent with the action queries, asking for HasuraRole
+// instead of the admin role.
+func (cfg *Config) sessionVariables() SessionVariablesBody {
+	return SessionVariablesBody{XHasuraRole: cfg.HasuraRole}
+}
@@ -0,0 +1,78 @@
+package sdk
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zecrey-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "hasura-key")
+	if err := ioutil.WriteFile(file, []byte("key-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	provider := FileCredentials(file, "", "user")
+	cred, err := provider.Credential(context.Background())
+	if err != nil || cred.Value != "key-1" {
+		t.Fatalf("unexpected credential: %+v, %v", cred, err)
+	}
+	if err := ioutil.WriteFile(file, []byte("key-2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(file, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if cred, _ := provider.Credential(context.Background()); cred.Value != "key-2" {
+		t.Fatalf("rotated key not reloaded: %s", cred.Value)
+	}
+}
+
+func TestRefreshableCredentials(t *testing.T) {
+	fetches := 0
+	provider := RefreshableCredentials(func(ctx context.Context) (*Credential, error) {
+		fetches++
+		return &Credential{Header: AuthorizationHeader, Value: "Bearer token", ExpiresAt: time.Now().Add(2 * time.Minute)}, nil
+	}, time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Credential(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("expected a cached token, fetched %d times", fetches)
+	}
+}
+
+func TestPost2HasuraCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(AuthorizationHeader) != "Bearer jwt" || r.Header.Get(HasuraRoleHeader) != "user" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer server.Close()
+
+	old := GetConfig()
+	defer SetConfig(old)
+	cfg := DefaultConfig()
+	cfg.HasuraUrl = server.URL
+	cfg.HasuraCredentials = BearerToken("jwt", "user")
+	if err := SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Post2Hasura([]byte(`{"query": "{}"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if vars := cfg.sessionVariables(); vars.XHasuraRole != "" {
+		t.Fatalf("unexpected session role: %s", vars.XHasuraRole)
+	}
+}
@@ -0,0 +1,218 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrAccountNotFound     = errors.New("account not found")
+	ErrNonceTooLow         = errors.New("nonce too low")
+	ErrOfferExpired        = errors.New("offer expired")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrRateLimited         = errors.New("rate limited")
+)
+
+// APIError is returned for every non-200 response of the marketplace,
+// legend and hasura endpoints. Use errors.Is with the Err* sentinels to
+// branch on the kind of failure and errors.As to inspect the details.
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Method     string // HTTP method
+	Host       string
+	Endpoint   string // request path without query
+	Code       string // backend error code, if any
+	Message    string // backend error message, or the raw body
+	RequestId  string
+	Retryable  bool
+
+	kind error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: status %d", e.Method, e.Endpoint, e.StatusCode)
+	if e.Code != "" {
+		msg += ", code " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns the sentinel error the response was classified as, if any.
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+type errorMatcher struct {
+	kind     error
+	keywords [][]string // any group whose keywords all appear in the message
+}
+
+var (
+	errorMatchers = []errorMatcher{
+		{kind: ErrAccountNotFound, keywords: [][]string{
+			{"account", "not found"}, {"account", "not exist"}, {"account", "not registered"}}},
+		{kind: ErrNonceTooLow, keywords: [][]string{
+			{"nonce", "too low"}, {"invalid nonce"}, {"nonce", "expected"}}},
+		{kind: ErrOfferExpired, keywords: [][]string{
+			{"offer", "expired"}, {"offer", "expire"}}},
+		{kind: ErrInsufficientBalance, keywords: [][]string{
+			{"insufficient"}, {"balance", "not enough"}, {"not enough", "asset"}}},
+	}
+
+	errorCodes     = make(map[string]error)
+	errorCodesLock sync.RWMutex
+)
+
+// RegisterErrorCode maps a backend error code to one of the Err* sentinels,
+// taking precedence over the message based classification.
+func RegisterErrorCode(code string, kind error) {
+	errorCodesLock.Lock()
+	defer errorCodesLock.Unlock()
+	errorCodes[code] = kind
+}
+
+func newAPIError(req *http.Request, resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		Host:       req.URL.Host,
+		Endpoint:   req.URL.Path,
+		RequestId:  requestIdOf(resp.Header),
+		Retryable:  isRetryableStatus(resp.StatusCode),
+	}
+	e.Code, e.Message = parseErrorBody(body)
+	e.kind = classifyError(e)
+	return e
+}
+
+func newGraphQLError(req *http.Request, resp *http.Response, code, message string) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		Host:       req.URL.Host,
+		Endpoint:   req.URL.Path,
+		Code:       code,
+		Message:    message,
+		RequestId:  requestIdOf(resp.Header),
+	}
+	e.kind = classifyError(e)
+	return e
+}
+
+func requestIdOf(header http.Header) string {
+	for _, key := range []string{"X-Request-Id", "X-Trace-Id", "X-Amzn-Trace-Id"} {
+		if id := header.Get(key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseErrorBody accepts {"code": ..., "message"|"msg"|"error": ...} bodies
+// and falls back to the raw body as message.
+func parseErrorBody(body []byte) (code, message string) {
+	var payload struct {
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+		Msg     string          `json:"msg"`
+		Error   string          `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", strings.TrimSpace(string(body))
+	}
+	if len(payload.Code) > 0 {
+		code = strings.Trim(string(payload.Code), `"`)
+		if code == "null" {
+			code = ""
+		}
+	}
+	switch {
+	case payload.Message != "":
+		message = payload.Message
+	case payload.Msg != "":
+		message = payload.Msg
+	case payload.Error != "":
+		message = payload.Error
+	default:
+		message = strings.TrimSpace(string(body))
+	}
+	return code, message
+}
+
+func classifyError(e *APIError) error {
+	if e.Code != "" {
+		errorCodesLock.RLock()
+		kind, ok := errorCodes[e.Code]
+		errorCodesLock.RUnlock()
+		if ok {
+			return kind
+		}
+	}
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	msg := strings.ToLower(e.Message)
+	for _, matcher := range errorMatchers {
+		for _, group := range matcher.keywords {
+			if containsAll(msg, group) {
+				return matcher.kind
+			}
+		}
+	}
+	if e.StatusCode == http.StatusNotFound && strings.Contains(e.Endpoint, "/account/") {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+func containsAll(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if !strings.Contains(s, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkGraphQLErrors turns a 200 hasura response carrying an "errors" array into an APIError.
+func checkGraphQLErrors(req *http.Request, resp *http.Response, body []byte) error {
+	var payload struct {
+		Errors []struct {
+			Message    string `json:"message"`
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Errors) == 0 {
+		return nil
+	}
+	first := payload.Errors[0]
+	message := first.Message
+	if n := len(payload.Errors); n > 1 {
+		message += " (and " + strconv.Itoa(n-1) + " more)"
+	}
+	return newGraphQLError(req, resp, first.Extensions.Code, message)
+}
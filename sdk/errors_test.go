@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/account/getAccountByAccountName":
+			w.Header().Set("X-Request-Id", "req-1")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 20001, "message": "account not exist"}`))
+		case "/api/v1/offer/acceptOffer":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("offer is expired"))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+
+	err := httpGet(context.Background(), cfg, server.URL+"/api/v1/account/getAccountByAccountName?account_name=alice", &RespGetAccountByAccountName{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "20001" || apiErr.RequestId != "req-1" {
+		t.Fatalf("unexpected APIError: %+v", apiErr)
+	}
+	if apiErr.Endpoint != "/api/v1/account/getAccountByAccountName" {
+		t.Fatalf("unexpected endpoint: %s", apiErr.Endpoint)
+	}
+	if !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+
+	err = httpPostForm(context.Background(), cfg, server.URL+"/api/v1/offer/acceptOffer", nil, &RespAcceptOffer{})
+	if !errors.Is(err, ErrOfferExpired) {
+		t.Fatalf("expected ErrOfferExpired, got %v", err)
+	}
+
+	err = httpGet(context.Background(), cfg, server.URL+"/api/v1/info/getLayer2BasicInfo", &RespGetLayer2BasicInfo{})
+	if !errors.As(err, &apiErr) || !apiErr.Retryable {
+		t.Fatalf("expected retryable APIError, got %v", err)
+	}
+
+	errorCodesLock.RLock()
+	previous, registered := errorCodes["30001"]
+	errorCodesLock.RUnlock()
+	t.Cleanup(func() {
+		errorCodesLock.Lock()
+		defer errorCodesLock.Unlock()
+		if registered {
+			errorCodes["30001"] = previous
+		} else {
+			delete(errorCodes, "30001")
+		}
+	})
+	RegisterErrorCode("30001", ErrNonceTooLow)
+	apiErr = &APIError{StatusCode: http.StatusBadRequest, Code: "30001"}
+	if classifyError(apiErr) != ErrNonceTooLow {
+		t.Fatal("registered error code not honored")
+	}
+}
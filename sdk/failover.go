@@ -0,0 +1,369 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultEndpointCooldown is how long a failed endpoint is skipped while
+// other endpoints are available.
+const defaultEndpointCooldown = 30 * time.Second
+
+// endpointPool is the ordered list of endpoints of one service. Requests stick
+// to the current endpoint until it fails with a connection error, and then move
+// on to the next healthy one.
+type endpointPool struct {
+	family EndpointFamily
+	urls   []string
+
+	lock      sync.Mutex
+	current   int
+	downUntil []time.Time
+	cooldown  time.Duration
+
+	// stop ends the health check loop, nil while none runs
+	stop chan struct{}
+}
+
+// endpointPools are the pools of a config, shared by the copies made after
+// its first request.
+type endpointPools struct {
+	lock   sync.Mutex
+	pools  map[string]*endpointPool
+	closed bool
+}
+
+// endpointPoolsLock guards the lazy creation of Config.pools.
+var endpointPoolsLock sync.Mutex
+
+func (cfg *Config) endpointPools() *endpointPools {
+	endpointPoolsLock.Lock()
+	defer endpointPoolsLock.Unlock()
+	if cfg.pools == nil {
+		cfg.pools = &endpointPools{pools: make(map[string]*endpointPool)}
+	}
+	return cfg.pools
+}
+
+// Close stops the background health checks of cfg and its copies. The
+// endpoints still fail over on errors afterwards.
+func (cfg *Config) Close() error {
+	set := cfg.endpointPools()
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	set.closed = true
+	for _, pool := range set.pools {
+		if pool.stop != nil {
+			close(pool.stop)
+			pool.stop = nil
+		}
+	}
+	return nil
+}
+
+// endpointsOf returns the configured endpoints of family, the primary one first.
+func (cfg *Config) endpointsOf(family EndpointFamily) []string {
+	var primary string
+	var others []string
+	switch family {
+	case FamilyMarketplace:
+		primary, others = cfg.NftMarketUrl, cfg.NftMarketUrls
+	case FamilyLegend:
+		primary, others = cfg.LegendUrl, cfg.LegendUrls
+	case FamilyL1RPC:
+		primary, others = cfg.ChainRpcUrl, cfg.ChainRpcUrls
+	default:
+		return nil
+	}
+	return dedupe(append([]string{primary}, others...))
+}
+
+// endpoints returns the pool of family of cfg, nil when it has a single endpoint.
+func (cfg *Config) endpoints(family EndpointFamily) *endpointPool {
+	urls := cfg.endpointsOf(family)
+	if len(urls) < 2 {
+		return nil
+	}
+	key := string(family) + "|" + strings.Join(urls, "|")
+	set := cfg.endpointPools()
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	pool, ok := set.pools[key]
+	if !ok {
+		pool = &endpointPool{family: family, urls: urls, downUntil: make([]time.Time, len(urls)), cooldown: defaultEndpointCooldown}
+		set.pools[key] = pool
+	}
+	if cfg.HealthCheckInterval > 0 && !set.closed && pool.stop == nil {
+		pool.stop = make(chan struct{})
+		go pool.checkLoop(cfg.HealthCheckInterval, cfg.httpClient(), pool.stop)
+	}
+	return pool
+}
+
+// order returns the endpoint indexes to try: the current one, then the healthy
+// ones in configured order, then the ones still cooling down as a last resort.
+func (p *endpointPool) order() []int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	order := []int{p.current}
+	var down []int
+	for i := range p.urls {
+		if i == p.current {
+			continue
+		}
+		if now.Before(p.downUntil[i]) {
+			down = append(down, i)
+		} else {
+			order = append(order, i)
+		}
+	}
+	return append(order, down...)
+}
+
+func (p *endpointPool) markUp(i int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.downUntil[i] = time.Time{}
+	p.current = i
+}
+
+func (p *endpointPool) markDown(i int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.downUntil[i] = time.Now().Add(p.cooldown)
+}
+
+// Current returns the endpoint requests are sent to.
+func (p *endpointPool) Current() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.urls[p.current]
+}
+
+// match returns the endpoint that is a prefix of rawURL.
+func (p *endpointPool) match(rawURL string) (string, bool) {
+	for _, base := range p.urls {
+		if strings.HasPrefix(rawURL, base) {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// check probes every endpoint once and updates their health.
+func (p *endpointPool) check(ctx context.Context, hc *http.Client) map[string]error {
+	result := make(map[string]error, len(p.urls))
+	for i, base := range p.urls {
+		err := probe(ctx, hc, p.family, base)
+		result[base] = err
+		if err != nil {
+			p.markDown(i)
+			continue
+		}
+		p.lock.Lock()
+		p.downUntil[i] = time.Time{}
+		if now := time.Now(); now.Before(p.downUntil[p.current]) {
+			// the current endpoint is down, switch to this healthy one
+			p.current = i
+		}
+		p.lock.Unlock()
+	}
+	return result
+}
+
+func (p *endpointPool) checkLoop(interval time.Duration, hc *http.Client, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		p.check(ctx, hc)
+		cancel()
+	}
+}
+
+// probe reports whether an endpoint answers at all: any HTTP response is
+// healthy for the REST services, and eth_chainId must succeed for the L1 RPC.
+func probe(ctx context.Context, hc *http.Client, family EndpointFamily, base string) error {
+	var (
+		req *http.Request
+		err error
+	)
+	if family == FamilyL1RPC {
+		if !isHTTPURL(base) {
+			return nil
+		}
+		body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`)
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, base, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, base, nil)
+	}
+	if err != nil {
+		return err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	if family == FamilyL1RPC && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("eth_chainId: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// CheckEndpoints probes every marketplace, legend and L1 RPC endpoint of cfg
+// and updates the endpoints used for failover. It returns the error of each
+// unhealthy endpoint, keyed by url, and nil for the healthy ones.
+func CheckEndpoints(ctx context.Context, cfg *Config) map[string]error {
+	result := make(map[string]error)
+	for _, family := range []EndpointFamily{FamilyMarketplace, FamilyLegend, FamilyL1RPC} {
+		pool := cfg.endpoints(family)
+		if pool == nil {
+			for _, base := range cfg.endpointsOf(family) {
+				result[base] = probe(ctx, cfg.httpClient(), family, base)
+			}
+			continue
+		}
+		for base, err := range pool.check(ctx, cfg.httpClient()) {
+			result[base] = err
+		}
+	}
+	return result
+}
+
+// failoverTransport sends requests to the current endpoint of pool and fails
+// over to the next one on connection errors.
+type failoverTransport struct {
+	base http.RoundTripper
+	pool *endpointPool
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	matched, ok := t.pool.match(req.URL.String())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	var lastErr error
+	for attempt, i := range t.pool.order() {
+		r, err := rewriteRequest(req, matched, t.pool.urls[i], attempt > 0)
+		if err != nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(r)
+		if err == nil {
+			t.pool.markUp(i)
+			return resp, nil
+		}
+		lastErr = err
+		if !canFailover(req, err) {
+			return nil, err
+		}
+		t.pool.markDown(i)
+	}
+	return nil, lastErr
+}
+
+// rewriteRequest returns a copy of req sent to target instead of base.
+func rewriteRequest(req *http.Request, base, target string, rewind bool) (*http.Request, error) {
+	u, err := url.Parse(target + strings.TrimPrefix(req.URL.String(), base))
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.URL = u
+	r.Host = u.Host
+	if rewind && req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body can not be resent")
+		}
+		if r.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// canFailover reports whether req may be resent to another endpoint after err.
+// Reads fail over on any connection error, other requests only when the
+// connection could not be established, so they are never sent twice.
+func canFailover(req *http.Request, err error) bool {
+	if req.Context().Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// clientFor returns the http client of family, failing over between its
+// endpoints and, for the L1 RPC, waiting on its rate limiter.
+func (cfg *Config) clientFor(family EndpointFamily) *http.Client {
+	hc := cfg.httpClient()
+	pool := cfg.endpoints(family)
+	var limiter *RateLimiter
+	if family == FamilyL1RPC {
+		limiter = cfg.RateLimiters[FamilyL1RPC]
+	}
+	if pool == nil && limiter == nil {
+		return hc
+	}
+	transport := hc.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if pool != nil {
+		transport = &failoverTransport{base: transport, pool: pool}
+	}
+	if limiter != nil {
+		transport = &rateLimitedTransport{base: transport, limiter: limiter}
+	}
+	wrapped := *hc
+	wrapped.Transport = transport
+	return &wrapped
+}
+
+func isHTTPURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")
+}
+
+func dedupe(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	out := make([]string, 0, len(urls))
+	for _, u := range urls {
+		u = strings.TrimRight(strings.TrimSpace(u), "/")
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		out = append(out, u)
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadUrl := dead.URL
+	dead.Close()
+
+	requests := 0
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/rpc" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x61"}`))
+			return
+		}
+		w.Write([]byte(`{"nonce": 3}`))
+	}))
+	defer live.Close()
+
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.LegendUrl = deadUrl
+	cfg.LegendUrls = []string{live.URL}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result := &RespGetNextNonce{}
+		if err := httpGet(ctx, cfg, cfg.LegendUrl+"/api/v1/tx/getNextNonce?account_index=1", result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Nonce != 3 {
+			t.Fatalf("unexpected nonce: %d", result.Nonce)
+		}
+	}
+	if current := cfg.endpoints(FamilyLegend).Current(); current != live.URL {
+		t.Fatalf("expected sticky fallback endpoint, got %s", current)
+	}
+	if err := httpPostForm(ctx, cfg, cfg.LegendUrl+"/api/v1/register/applyRegisterHost", url.Values{"a": {"b"}}, &RespGetNextNonce{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.ChainRpcUrl = deadUrl + "/rpc"
+	cfg.ChainRpcUrls = []string{live.URL + "/rpc"}
+	providerClient, err := dialChain(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainId, err := providerClient.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chainId.Int64() != 97 {
+		t.Fatalf("unexpected chain id: %s", chainId)
+	}
+
+	health := CheckEndpoints(ctx, cfg)
+	if health[deadUrl] == nil || health[live.URL] != nil {
+		t.Fatalf("unexpected health: %v", health)
+	}
+}
+
+func TestFailoverHealthCheckClose(t *testing.T) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.LegendUrl = server.URL + "/a"
+	cfg.LegendUrls = []string{server.URL + "/b"}
+	cfg.HealthCheckInterval = time.Millisecond
+	other := DefaultConfig()
+	other.Settings = cfg.Settings
+	other.HealthCheckInterval = cfg.HealthCheckInterval
+	if cfg.endpoints(FamilyLegend) == other.endpoints(FamilyLegend) {
+		t.Fatal("configs share their endpoint pools")
+	}
+	other.Close()
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&probes) == 0 {
+		t.Fatal("endpoints not probed")
+	}
+	cfg.Close()
+	time.Sleep(5 * time.Millisecond)
+	stopped := atomic.LoadInt32(&probes)
+	time.Sleep(20 * time.Millisecond)
+	if probes := atomic.LoadInt32(&probes); probes != stopped {
+		t.Fatalf("health check still running after Close: %d probes", probes-stopped)
+	}
+}
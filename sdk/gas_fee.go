@@ -0,0 +1,249 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrGasFeeTooHigh is returned, wrapped, when the gas fee of a tx exceeds its cap.
+var ErrGasFeeTooHigh = errors.New("gas fee exceeds the max fee")
+
+// GasFee is the gas fee of a tx, paid to the gas account.
+type GasFee struct {
+	AccountIndex int64    `json:"gas_account_index"`
+	AssetId      int64    `json:"gas_fee_asset_id"`
+	Amount       *big.Int `json:"gas_fee_asset_amount"`
+}
+
+// GasFeePolicy selects the asset paying the gas fee of the txs signed by the
+// SDK and caps the fee. Zero values select the default asset without cap.
+type GasFeePolicy struct {
+	// AssetId, when set, pays the gas fee. Otherwise the asset moved by the
+	// tx pays when the legend accepts it as fee asset, else the first fee asset.
+	AssetId *int64
+	// MaxFee caps the gas fee by fee asset id.
+	MaxFee map[int64]*big.Int
+}
+
+type gasFeePolicyKey struct{}
+
+// WithGasFeePolicy returns a copy of ctx whose operations select their gas
+// fee with policy instead of Config.GasFee.
+func WithGasFeePolicy(ctx context.Context, policy *GasFeePolicy) context.Context {
+	return context.WithValue(ctx, gasFeePolicyKey{}, policy)
+}
+
+func gasFeePolicy(ctx context.Context, cfg *Config) *GasFeePolicy {
+	if policy, ok := ctx.Value(gasFeePolicyKey{}).(*GasFeePolicy); ok && policy != nil {
+		return policy
+	}
+	if cfg.GasFee != nil {
+		return cfg.GasFee
+	}
+	return &GasFeePolicy{}
+}
+
+// GetGasFeeAssets returns the assets the legend accepts as gas fee.
+func GetGasFeeAssets() ([]*GasFeeAsset, error) {
+	return GetGasFeeAssetsWithContext(context.Background())
+}
+
+func GetGasFeeAssetsWithContext(ctx context.Context) ([]*GasFeeAsset, error) {
+	return getGasFeeAssets(ctx, GetConfig())
+}
+
+// GetGasFee returns the current gas fee of a tx of txType paid in the fee
+// asset assetId.
+func GetGasFee(txType int, assetId int64) (*big.Int, error) {
+	return GetGasFeeWithContext(context.Background(), txType, assetId)
+}
+
+func GetGasFeeWithContext(ctx context.Context, txType int, assetId int64) (*big.Int, error) {
+	return getGasFee(ctx, GetConfig(), txType, assetId)
+}
+
+// EstimateGasFee returns the gas fee a tx of txType moving assetId would be
+// signed with, selected by the gas fee policy of the config.
+func EstimateGasFee(txType int, assetId int64) (*GasFee, error) {
+	return EstimateGasFeeWithContext(context.Background(), txType, assetId)
+}
+
+// EstimateGasFeeWithContext is EstimateGasFee with the policy of WithGasFeePolicy
+// when ctx carries one.
+func EstimateGasFeeWithContext(ctx context.Context, txType int, assetId int64) (*GasFee, error) {
+	return selectGasFee(ctx, GetConfig(), txType, assetId)
+}
+
+func getGasFeeAssets(ctx context.Context, cfg *Config) ([]*GasFeeAsset, error) {
+	result := &RespGetGasFeeAssetList{}
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+"/api/v1/info/getGasFeeAssetList", result); err != nil {
+		return nil, err
+	}
+	return result.Assets, nil
+}
+
+func getGasFee(ctx context.Context, cfg *Config, txType int, assetId int64) (*big.Int, error) {
+	result := &RespGetGasFee{}
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+fmt.Sprintf("/api/v1/info/getGasFee?asset_id=%d&tx_type=%d", assetId, txType), result); err != nil {
+		return nil, err
+	}
+	amount, ok := new(big.Int).SetString(result.GasFee, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid gas fee %q", result.GasFee)
+	}
+	return amount, nil
+}
+
+// selectGasFee returns the gas fee of a tx of txType moving assetId, as
+// selected and capped by the gas fee policy of ctx.
+func selectGasFee(ctx context.Context, cfg *Config, txType int, assetId int64) (*GasFee, error) {
+	policy := gasFeePolicy(ctx, cfg)
+	account := &RespGetGasAccount{}
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+"/api/v1/info/getGasAccount", account); err != nil {
+		return nil, err
+	}
+	assets, err := getGasFeeAssets(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(assets) == 0 {
+		return nil, errors.New("no gas fee asset")
+	}
+	feeAssetId := assets[0].AssetId
+	if policy.AssetId != nil {
+		feeAssetId = *policy.AssetId
+		if !isGasFeeAsset(assets, feeAssetId) {
+			return nil, fmt.Errorf("asset %d is not a gas fee asset", feeAssetId)
+		}
+	} else if isGasFeeAsset(assets, assetId) {
+		feeAssetId = assetId
+	}
+	amount, err := getGasFee(ctx, cfg, txType, feeAssetId)
+	if err != nil {
+		return nil, err
+	}
+	fee := &GasFee{AccountIndex: account.AccountIndex, AssetId: feeAssetId, Amount: amount}
+	if err := checkGasFee(fee, policy.MaxFee); err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+func isGasFeeAsset(assets []*GasFeeAsset, assetId int64) bool {
+	for _, asset := range assets {
+		if asset.AssetId == assetId {
+			return true
+		}
+	}
+	return false
+}
+
+func checkGasFee(fee *GasFee, maxFee map[int64]*big.Int) error {
+	if fee.Amount == nil {
+		return errors.New("gas fee is not set")
+	}
+	if max := maxFee[fee.AssetId]; max != nil && fee.Amount.Cmp(max) > 0 {
+		return fmt.Errorf("%w: %s of asset %d exceeds %s", ErrGasFeeTooHigh, fee.Amount, fee.AssetId, max)
+	}
+	return nil
+}
+
+// feeTx is implemented by the txs paying a gas fee.
+type feeTx interface {
+	SignableTx
+	gasFee() *GasFee
+	setGasFee(fee *GasFee)
+}
+
+// newFeeTx returns the tx of an Envelope operation, nil for the offers which
+// pay no gas fee.
+func newFeeTx(op string) feeTx {
+	switch op {
+	case OpCreateCollection:
+		return &CreateCollectionTxInfo{}
+	case OpMintNft:
+		return &MintNftTxInfo{}
+	case OpTransferNft:
+		return &TransferNftTxInfo{}
+	case OpWithdrawNft:
+		return &WithdrawNftTxInfo{}
+	case OpAcceptOffer:
+		return &AtomicMatchTxInfo{}
+	}
+	return nil
+}
+
+// paymentAssetId returns the asset tx pays with, which pays its gas fee when
+// the legend accepts it: the offer asset of matches, else the default asset.
+func paymentAssetId(tx feeTx) int64 {
+	if match, ok := tx.(*AtomicMatchTxInfo); ok && match.BuyOffer != nil {
+		return match.BuyOffer.AssetId
+	}
+	return nftGasFeeAssetId
+}
+
+func (tx *CreateCollectionTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *CreateCollectionTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
+
+func (tx *MintNftTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *MintNftTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
+
+func (tx *TransferNftTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *TransferNftTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
+
+func (tx *WithdrawNftTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *WithdrawNftTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
+
+func (tx *AtomicMatchTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *AtomicMatchTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
+
+func (tx *TransferTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *TransferTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
+
+func (tx *WithdrawTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *WithdrawTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
+
+func (tx *CancelOfferTxInfo) gasFee() *GasFee {
+	return &GasFee{AccountIndex: tx.GasAccountIndex, AssetId: tx.GasFeeAssetId, Amount: tx.GasFeeAssetAmount}
+}
+
+func (tx *CancelOfferTxInfo) setGasFee(fee *GasFee) {
+	tx.GasAccountIndex, tx.GasFeeAssetId, tx.GasFeeAssetAmount = fee.AccountIndex, fee.AssetId, fee.Amount
+}
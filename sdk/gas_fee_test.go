@@ -0,0 +1,138 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGasFee(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newLegendTestConfig(t, map[string]http.HandlerFunc{
+		"/api/v1/info/getGasFeeAssetList": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"assets": [{"asset_id": 0}, {"asset_id": 2}]}`))
+		},
+		"/api/v1/info/getGasFee": func(w http.ResponseWriter, r *http.Request) {
+			// the fee depends on the tx type and the fee asset
+			fmt.Fprintf(w, `{"gas_fee": "%s%s"}`, r.URL.Query().Get("tx_type"), r.URL.Query().Get("asset_id"))
+		},
+		"/api/v1/tx/sendTx": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"tx_id": "0x01"}`))
+		},
+	})
+	old := GetConfig()
+	defer SetConfig(old)
+	if err := SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	fee, err := GetGasFee(TxTypeMintNft, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee.String() != fmt.Sprintf("%d2", TxTypeMintNft) {
+		t.Fatalf("unexpected fee %s", fee)
+	}
+	// the moved asset pays when it is a fee asset, else the first fee asset
+	for assetId, feeAssetId := range map[int64]int64{2: 2, 5: 0} {
+		estimate, err := EstimateGasFee(TxTypeTransfer, assetId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if estimate.AccountIndex != 9 || estimate.AssetId != feeAssetId || estimate.Amount.String() != fmt.Sprintf("%d%d", TxTypeTransfer, feeAssetId) {
+			t.Fatalf("unexpected estimate %+v for asset %d", estimate, assetId)
+		}
+	}
+	feeAssetId := int64(2)
+	ctx := WithGasFeePolicy(context.Background(), &GasFeePolicy{AssetId: &feeAssetId})
+	if estimate, err := EstimateGasFeeWithContext(ctx, TxTypeTransfer, 5); err != nil || estimate.AssetId != 2 {
+		t.Fatalf("fee asset not chosen: %+v %v", estimate, err)
+	}
+	feeAssetId = 5
+	if _, err := EstimateGasFeeWithContext(ctx, TxTypeTransfer, 0); err == nil {
+		t.Fatal("fee paid in an asset the legend does not accept")
+	}
+
+	c := newClientWithKeyManager(cfg, nil, "alice", key, l2pkOfKey(key))
+	resp, err := c.Transfer("bob", 2, big.NewInt(1000), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GasFee == nil || resp.GasFee.AssetId != 2 || resp.GasFee.Amount.String() != fmt.Sprintf("%d2", TxTypeTransfer) {
+		t.Fatalf("unexpected signed fee %+v", resp.GasFee)
+	}
+	ctx = WithGasFeePolicy(context.Background(), &GasFeePolicy{MaxFee: map[int64]*big.Int{2: big.NewInt(10)}})
+	if _, err := c.TransferWithContext(ctx, "bob", 2, big.NewInt(1000), ""); !errors.Is(err, ErrGasFeeTooHigh) {
+		t.Fatalf("fee above the cap signed: %v", err)
+	}
+
+	km := NewPolicyKeyManager(key, SigningPolicy{MaxGasFee: map[int64]*big.Int{0: big.NewInt(10)}})
+	tx := &TransferNftTxInfo{ToAccountIndex: 2, GasFeeAssetId: 0, GasFeeAssetAmount: big.NewInt(11)}
+	if _, err := ConstructTransferNftTx(km, tx); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("fee above the signing cap signed: %v", err)
+	}
+	tx.GasFeeAssetAmount = nil
+	if _, err := ConstructTransferNftTx(key, tx); err == nil {
+		t.Fatal("tx without gas fee signed")
+	}
+
+	// a prepared match pays gas in its offer asset, and unknown fields survive
+	txInfo, matchFee, err := setTxGasFee(context.Background(), cfg, &AtomicMatchTxInfo{},
+		`{"AccountIndex": 1, "BuyOffer": {"AssetId": 2}, "GasFeeAssetAmount": 1, "Extra": "kept"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matchFee.AssetId != 2 || !strings.Contains(txInfo, `"Extra":"kept"`) || !strings.Contains(txInfo, `"GasFeeAssetId":2`) {
+		t.Fatalf("unexpected prepared match %s with fee %+v", txInfo, matchFee)
+	}
+}
+
+// newLegendTestConfig returns the config of a fake legend and marketplace,
+// serving the lookups behind signing a tx: alice.zec is account 1 and bob.zec
+// account 2, the gas account is 9, asset 0 pays a fee of 100 and the next
+// nonce is 0. routes override or add routes by path.
+func newLegendTestConfig(t *testing.T, routes map[string]http.HandlerFunc) *Config {
+	defaults := map[string]http.HandlerFunc{
+		"/api/v1/account/getAccountByAccountName": func(w http.ResponseWriter, r *http.Request) {
+			index := map[string]int64{"alice.zec": 1, "bob.zec": 2}[r.URL.Query().Get("account_name")]
+			fmt.Fprintf(w, `{"account": {"account_index": %d}}`, index)
+		},
+		"/api/v1/info/getGasAccount": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"account_index": 9}`))
+		},
+		"/api/v1/info/getGasFeeAssetList": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"assets": [{"asset_id": 0}]}`))
+		},
+		"/api/v1/info/getGasFee": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"gas_fee": "100"}`))
+		},
+		"/api/v1/tx/getNextNonce": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"nonce": 0}`))
+		},
+	}
+	for path, route := range routes {
+		defaults[path] = route
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, ok := defaults[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		route(w, r)
+	}))
+	t.Cleanup(server.Close)
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	cfg.LegendUrl = server.URL
+	return cfg
+}
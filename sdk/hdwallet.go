@@ -0,0 +1,188 @@
+package sdk
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/zecrey-labs/zecrey-crypto/util/eddsaHelper"
+)
+
+// DefaultDerivationPath is the BIP-44 path of the L1 key of account N, the one
+// used by MetaMask and most Ethereum wallets. The L2 seed is derived from the
+// L1 key like CreateL1Account does, so the mnemonic restores both.
+const DefaultDerivationPath = "m/44'/60'/0'/0/%d"
+
+const hardenedOffset = 0x80000000
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// NewMnemonic generates a BIP-39 mnemonic of 12 (128 bits) or 24 (256 bits) words.
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// HDWallet derives the keys of any number of accounts from one mnemonic.
+type HDWallet struct {
+	seed []byte
+}
+
+// NewHDWallet imports a BIP-39 mnemonic, passphrase is the optional 25th word.
+func NewHDWallet(mnemonic, passphrase string) (*HDWallet, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	return &HDWallet{seed: bip39.NewSeed(mnemonic, passphrase)}, nil
+}
+
+// HDAccount holds the keys of one derived account.
+type HDAccount struct {
+	Path         string
+	L1Address    string
+	L1PrivateKey string // hex, without 0x
+	Seed         string // L2 seed, as returned by CreateL1Account
+	L2Pk         string
+
+	l1Key *ecdsa.PrivateKey
+}
+
+// Account derives account index along DefaultDerivationPath.
+func (w *HDWallet) Account(index uint32) (*HDAccount, error) {
+	return w.Derive(fmt.Sprintf(DefaultDerivationPath, index))
+}
+
+// Accounts derives the accounts from..from+count-1. The range must stay below
+// the hardened indexes, as account indexes are non-hardened.
+func (w *HDWallet) Accounts(from, count uint32) ([]*HDAccount, error) {
+	if uint64(from)+uint64(count) > hardenedOffset {
+		return nil, fmt.Errorf("account range %d+%d exceeds the index limit %d", from, count, uint32(hardenedOffset))
+	}
+	accounts := make([]*HDAccount, 0, count)
+	for i := from; i < from+count; i++ {
+		account, err := w.Account(i)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// Derive derives the account of a BIP-32 path such as "m/44'/60'/0'/0/7".
+func (w *HDWallet) Derive(path string) (*HDAccount, error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(w.seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errors.New("invalid master key, use another mnemonic")
+	}
+	for _, index := range indexes {
+		if key, chainCode, err = deriveChild(key, chainCode, index); err != nil {
+			return nil, fmt.Errorf("derive %s: %v", path, err)
+		}
+	}
+	l1Key, err := crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+	if err != nil {
+		return nil, err
+	}
+	seed, err := eddsaHelper.GetEddsaSeed(l1Key)
+	if err != nil {
+		return nil, err
+	}
+	l2pk, err := l2pkOfSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &HDAccount{
+		Path:         path,
+		L1Address:    crypto.PubkeyToAddress(l1Key.PublicKey).Hex(),
+		L1PrivateKey: hex.EncodeToString(crypto.FromECDSA(l1Key)),
+		Seed:         seed,
+		L2Pk:         l2pk,
+		l1Key:        l1Key,
+	}, nil
+}
+
+// L1Key returns the L1 private key of the account.
+func (a *HDAccount) L1Key() *ecdsa.PrivateKey {
+	return a.l1Key
+}
+
+// KeyManager returns the L2 signer of the account.
+func (a *HDAccount) KeyManager() (KeyManager, error) {
+	return NewSeedKeyManager(a.Seed)
+}
+
+// deriveChild is the BIP-32 private parent key to private child key derivation.
+func deriveChild(key *big.Int, chainCode []byte, index uint32) (*big.Int, []byte, error) {
+	var data []byte
+	if index >= hardenedOffset {
+		data = append([]byte{0}, common.LeftPadBytes(key.Bytes(), 32)...)
+	} else {
+		priv, err := crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+	data = append(data, indexBytes[:]...)
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	child := il.Add(il, key)
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	return child, sum[32:], nil
+}
+
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) < 2 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q", path)
+		}
+		if hardened {
+			index += hardenedOffset
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
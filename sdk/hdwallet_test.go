@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHDWallet(t *testing.T) {
+	// BIP-39 test mnemonic, its first Ethereum address is well known
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	w, err := NewHDWallet(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := w.Account(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.L1Address != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Fatalf("unexpected address %s", account.L1Address)
+	}
+	l2pk, seed, err := GetSeedAndL2Pk(account.L1PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed != account.Seed || l2pk != account.L2Pk {
+		t.Fatal("l2 keys differ from the ones derived from the l1 key")
+	}
+
+	accounts, err := w.Accounts(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accounts[0].L1Address != account.L1Address || accounts[1].L1Address == accounts[2].L1Address {
+		t.Fatal("accounts not derived by index")
+	}
+	for _, r := range [][2]uint32{{hardenedOffset - 1, 2}, {1, ^uint32(0)}} {
+		if _, err := w.Accounts(r[0], r[1]); err == nil {
+			t.Fatalf("range %d+%d accepted", r[0], r[1])
+		}
+	}
+	if last, err := w.Accounts(hardenedOffset-1, 1); err != nil || len(last) != 1 {
+		t.Fatalf("last index rejected: %v", err)
+	}
+	if _, err := NewHDWallet(strings.Repeat("abandon ", 12), ""); err != ErrInvalidMnemonic {
+		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
+	}
+	if _, err := w.Derive("m/44'/x"); err == nil {
+		t.Fatal("invalid path accepted")
+	}
+
+	generated, err := NewMnemonic(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strings.Fields(generated)) != 24 {
+		t.Fatalf("unexpected mnemonic %q", generated)
+	}
+}
@@ -0,0 +1,126 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultHTTPClient is shared by every config without its own HTTPClient or Transport.
+var defaultHTTPClient = &http.Client{Transport: newDefaultTransport()}
+
+func newDefaultTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 100
+	t.MaxConnsPerHost = 100
+	t.MaxIdleConnsPerHost = 100
+	return t
+}
+
+// httpGet is used for reads and prepare-tx calls, which are always retried.
+func httpGet(ctx context.Context, cfg *Config, url string, result interface{}) error {
+	return withRetry(ctx, cfg, nil, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		return send(cfg, req, result)
+	})
+}
+
+// httpPostForm sends data once. Use httpSubmitForm for signed txs.
+func httpPostForm(ctx context.Context, cfg *Config, url string, data url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return send(cfg, req, result)
+}
+
+// httpSubmitForm posts the signed tx in data["transaction"], or data["tx_info"]
+// for the legend. A failed submission is retried only after the nonce of the
+// tx shows it was not accepted.
+func httpSubmitForm(ctx context.Context, cfg *Config, url string, data url.Values, result interface{}) error {
+	tx := data.Get("transaction")
+	if tx == "" {
+		tx = data.Get("tx_info")
+	}
+	accepted := acceptedByNonce(cfg, tx)
+	if accepted == nil {
+		return httpPostForm(ctx, cfg, url, data, result)
+	}
+	return withRetry(ctx, cfg, accepted, func() error {
+		return httpPostForm(ctx, cfg, url, data, result)
+	})
+}
+
+// httpPostJSON is used by the action read endpoints and retried like httpGet.
+func httpPostJSON(ctx context.Context, cfg *Config, url string, data []byte, result interface{}) error {
+	return withRetry(ctx, cfg, nil, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return send(cfg, req, result)
+	})
+}
+
+// send sends req through the rate limiter and the endpoints of the request
+// family, tracing and logging every request, and decodes a 200 response body
+// into result.
+func send(cfg *Config, req *http.Request, result interface{}) error {
+	body, err := sendRaw(cfg, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, result)
+}
+
+// sendRaw is send returning the raw response body. For hasura, GraphQL errors
+// in a 200 response fail the request.
+func sendRaw(cfg *Config, req *http.Request) ([]byte, error) {
+	family := cfg.familyOf(req.URL.String())
+	if err := cfg.wait(req.Context(), family); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req, done := cfg.telemetry().traceRequest(req)
+	resp, body, err := roundTrip(cfg.clientFor(family), req)
+	if err == nil && family == FamilyHasura {
+		err = checkGraphQLErrors(req, resp, body)
+	}
+	done(err)
+	logger := cfg.logger()
+	if err != nil {
+		logger.Debug("request failed", "method", req.Method, "host", req.URL.Host, "endpoint", req.URL.Path,
+			"duration", time.Since(start), "err", err)
+		return nil, err
+	}
+	logger.Debug("request done", "method", req.Method, "host", req.URL.Host, "endpoint", req.URL.Path,
+		"duration", time.Since(start))
+	return body, nil
+}
+
+// roundTrip sends req and returns an *APIError for any non-200 response.
+func roundTrip(hc *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil, newAPIError(req, resp, body)
+	}
+	return resp, body, nil
+}
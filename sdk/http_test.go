@@ -0,0 +1,43 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestInjectedTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"nonce": 7}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	cfg := DefaultConfig()
+	cfg.LegendUrl = server.URL
+	cfg.Transport = transport
+
+	result := &RespGetNextNonce{}
+	if err := httpGet(context.Background(), cfg, cfg.LegendUrl+"/api/v1/tx/getNextNonce?account_index=1", result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Nonce != 7 {
+		t.Fatalf("unexpected nonce: %d", result.Nonce)
+	}
+	if transport.calls != 1 {
+		t.Fatalf("injected transport not used, calls: %d", transport.calls)
+	}
+	if DefaultConfig().httpClient() != defaultHTTPClient {
+		t.Fatal("default config should share the default client")
+	}
+}
@@ -1,10 +1,15 @@
 package sdk
 
 import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"hash"
 
 	"github.com/consensys/gnark-crypto/signature"
 	"github.com/zecrey-labs/zecrey-crypto/ecc/ztwistededwards/tebn254"
+	"go.opentelemetry.io/otel/codes"
 )
 
 type KeyManager interface {
@@ -26,9 +31,143 @@ func NewSeedKeyManager(seed string) (KeyManager, error) {
 }
 
 func (key *SeedKeyManager) Sign(message []byte, hFunc hash.Hash) ([]byte, error) {
+	if key.privateKey == nil {
+		return nil, ErrKeyClosed
+	}
 	return key.privateKey.Sign(message, hFunc)
 }
 
 func (key *SeedKeyManager) Public() signature.PublicKey {
+	if key.privateKey == nil {
+		return nil
+	}
 	return key.privateKey.Public()
 }
+
+// Close overwrites the private key, signing fails afterwards.
+func (key *SeedKeyManager) Close() error {
+	if key.privateKey != nil {
+		*key.privateKey = tebn254.PrivateKey{}
+		key.privateKey = nil
+	}
+	return nil
+}
+
+// ErrKeyClosed is returned by the key managers of closed clients.
+var ErrKeyClosed = errors.New("key manager closed")
+
+type closedKeyManager struct{}
+
+func (closedKeyManager) Sign([]byte, hash.Hash) ([]byte, error) { return nil, ErrKeyClosed }
+func (closedKeyManager) Public() signature.PublicKey            { return nil }
+
+// l2pkOfKey returns the hex encoded L2 public key of key.
+func l2pkOfKey(key KeyManager) string {
+	pk := key.Public()
+	if pk == nil {
+		return ""
+	}
+	return hex.EncodeToString(pk.Bytes())
+}
+
+// ContextKeyManager is implemented by key managers whose signing can be
+// cancelled or bounded by a deadline, e.g. remote signers.
+type ContextKeyManager interface {
+	KeyManager
+	SignWithContext(ctx context.Context, message []byte, hFunc hash.Hash) ([]byte, error)
+}
+
+type ctxKeyManager struct {
+	KeyManager
+	ctx context.Context
+}
+
+// withContext binds ctx to every Sign call made through the returned KeyManager.
+func withContext(ctx context.Context, key KeyManager) KeyManager {
+	if key == nil {
+		return nil
+	}
+	return &ctxKeyManager{KeyManager: key, ctx: ctx}
+}
+
+func (key *ctxKeyManager) Sign(message []byte, hFunc hash.Hash) ([]byte, error) {
+	return key.sign(func(ctx context.Context) ([]byte, error) {
+		if signer, ok := key.KeyManager.(ContextKeyManager); ok {
+			return signer.SignWithContext(ctx, message, hFunc)
+		}
+		return key.KeyManager.Sign(message, hFunc)
+	})
+}
+
+func (key *ctxKeyManager) SignTx(_ context.Context, tx SignableTx, message []byte, hFunc hash.Hash) ([]byte, error) {
+	signer, ok := key.KeyManager.(TxKeyManager)
+	if !ok {
+		return key.Sign(message, hFunc)
+	}
+	return key.sign(func(ctx context.Context) ([]byte, error) {
+		return signer.SignTx(ctx, tx, message, hFunc)
+	})
+}
+
+func (key *ctxKeyManager) sign(sign func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if err := key.ctx.Err(); err != nil {
+		return nil, err
+	}
+	t := telemetryFromContext(key.ctx)
+	ctx, span := t.startStep(key.ctx, "sign")
+	defer span.End()
+	sig, err := sign(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	t.signatures.Add(ctx, 1)
+	return sig, nil
+}
+
+// TxKeyManager is implemented by key managers that inspect the tx behind the
+// message hash before signing it, e.g. PolicyKeyManager.
+type TxKeyManager interface {
+	KeyManager
+	SignTx(ctx context.Context, tx SignableTx, message []byte, hFunc hash.Hash) ([]byte, error)
+}
+
+// Message is an off-chain message authenticating a marketplace request, e.g.
+// UpdateCollection. The signed bytes are the timestamp followed by the action.
+type Message struct {
+	Action    string
+	Timestamp int64
+}
+
+// MessageUpdateCollection is the action of the message signed by UpdateCollection.
+const MessageUpdateCollection = "update_collection"
+
+func (msg *Message) Bytes() []byte {
+	return []byte(fmt.Sprintf("%d%s", msg.Timestamp, msg.Action))
+}
+
+// MessageKeyManager is implemented by key managers that inspect the off-chain
+// messages they sign, e.g. PolicyKeyManager.
+type MessageKeyManager interface {
+	KeyManager
+	SignMessage(ctx context.Context, msg *Message, hFunc hash.Hash) ([]byte, error)
+}
+
+func (key *ctxKeyManager) SignMessage(_ context.Context, msg *Message, hFunc hash.Hash) ([]byte, error) {
+	signer, ok := key.KeyManager.(MessageKeyManager)
+	if !ok {
+		return key.Sign(msg.Bytes(), hFunc)
+	}
+	return key.sign(func(ctx context.Context) ([]byte, error) {
+		return signer.SignMessage(ctx, msg, hFunc)
+	})
+}
+
+// signTx signs the hash of tx, handing tx over to key when it can use it.
+func signTx(key KeyManager, tx SignableTx, message []byte, hFunc hash.Hash) ([]byte, error) {
+	if signer, ok := key.(TxKeyManager); ok {
+		return signer.SignTx(context.Background(), tx, message, hFunc)
+	}
+	return key.Sign(message, hFunc)
+}
@@ -0,0 +1,311 @@
+package sdk
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zecrey-labs/zecrey-crypto/util/eddsaHelper"
+)
+
+const keystoreVersion = 3
+
+var ErrKeystoreExists = errors.New("keystore file already exists")
+
+// Keystore is a password encrypted key file holding the L2 EdDSA seed and
+// optionally the L1 ECDSA key. Both are encrypted with scrypt and AES-128-CTR
+// like the version 3 keystores of geth.
+type Keystore struct {
+	Path    string
+	ScryptN int
+	ScryptP int
+}
+
+// NewKeystore returns the keystore at path using the standard scrypt parameters.
+func NewKeystore(path string) *Keystore {
+	return &Keystore{Path: path, ScryptN: keystore.StandardScryptN, ScryptP: keystore.StandardScryptP}
+}
+
+type keystoreJSON struct {
+	Version     int                  `json:"version"`
+	Id          string               `json:"id"`
+	AccountName string               `json:"account_name,omitempty"`
+	L2Pk        string               `json:"l2pk"`
+	Address     string               `json:"address,omitempty"` // L1 address, if the L1 key is stored
+	Crypto      keystore.CryptoJSON  `json:"crypto"`
+	L1Crypto    *keystore.CryptoJSON `json:"l1_crypto,omitempty"`
+}
+
+// Create generates a new L1 key, derives the L2 seed from it and stores both,
+// or only the seed when storeL1Key is false.
+func (ks *Keystore) Create(accountName, password string, storeL1Key bool) (*KeystoreKeyManager, error) {
+	l1Key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	seed, err := eddsaHelper.GetEddsaSeed(l1Key)
+	if err != nil {
+		return nil, err
+	}
+	if !storeL1Key {
+		l1Key = nil
+	}
+	if err := ks.write(accountName, seed, l1Key, password, false); err != nil {
+		return nil, err
+	}
+	return newKeystoreKeyManager(accountName, seed, l1Key)
+}
+
+// Import stores an existing seed and/or L1 private key (hex). When seed is
+// empty it is derived from the L1 key, as CreateL1Account does.
+func (ks *Keystore) Import(accountName, seed, l1PrivateKey, password string) (*KeystoreKeyManager, error) {
+	var l1Key *ecdsa.PrivateKey
+	if l1PrivateKey != "" {
+		var err error
+		l1Key, err = crypto.ToECDSA(common.FromHex(l1PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid l1 private key: %v", err)
+		}
+	}
+	if seed == "" {
+		if l1Key == nil {
+			return nil, errors.New("either seed or l1 private key is required")
+		}
+		var err error
+		if seed, err = eddsaHelper.GetEddsaSeed(l1Key); err != nil {
+			return nil, err
+		}
+	}
+	km, err := newKeystoreKeyManager(accountName, seed, l1Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.write(accountName, seed, l1Key, password, false); err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+// Load decrypts the keystore into a KeyManager.
+func (ks *Keystore) Load(password string) (*KeystoreKeyManager, error) {
+	accountName, seed, l1Key, err := ks.decrypt(password)
+	if err != nil {
+		return nil, err
+	}
+	return newKeystoreKeyManager(accountName, seed, l1Key)
+}
+
+// Export returns the plaintext seed and L1 private key (hex, empty if not stored).
+func (ks *Keystore) Export(password string) (seed string, l1PrivateKey string, err error) {
+	_, seed, l1Key, err := ks.decrypt(password)
+	if err != nil {
+		return "", "", err
+	}
+	if l1Key != nil {
+		l1PrivateKey = hex.EncodeToString(crypto.FromECDSA(l1Key))
+	}
+	return seed, l1PrivateKey, nil
+}
+
+// ChangePassword re-encrypts the keystore with newPassword.
+func (ks *Keystore) ChangePassword(oldPassword, newPassword string) error {
+	accountName, seed, l1Key, err := ks.decrypt(oldPassword)
+	if err != nil {
+		return err
+	}
+	return ks.write(accountName, seed, l1Key, newPassword, true)
+}
+
+func (ks *Keystore) decrypt(password string) (accountName, seed string, l1Key *ecdsa.PrivateKey, err error) {
+	content, err := ioutil.ReadFile(ks.Path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	var file keystoreJSON
+	if err := json.Unmarshal(content, &file); err != nil {
+		return "", "", nil, fmt.Errorf("invalid keystore %s: %v", ks.Path, err)
+	}
+	if file.Version != keystoreVersion {
+		return "", "", nil, fmt.Errorf("unsupported keystore version: %d", file.Version)
+	}
+	seedBytes, err := keystore.DecryptDataV3(file.Crypto, password)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if file.L1Crypto != nil {
+		l1Bytes, err := keystore.DecryptDataV3(*file.L1Crypto, password)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if l1Key, err = crypto.ToECDSA(l1Bytes); err != nil {
+			return "", "", nil, err
+		}
+	}
+	return file.AccountName, string(seedBytes), l1Key, nil
+}
+
+func (ks *Keystore) write(accountName, seed string, l1Key *ecdsa.PrivateKey, password string, overwrite bool) error {
+	// fail early, before the expensive encryption; the link below is the real check
+	if !overwrite {
+		if _, err := os.Stat(ks.Path); err == nil {
+			return fmt.Errorf("%w: %s", ErrKeystoreExists, ks.Path)
+		}
+	}
+	if password == "" {
+		return errors.New("keystore password is required")
+	}
+	l2pk, err := l2pkOfSeed(seed)
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	file := keystoreJSON{
+		Version:     keystoreVersion,
+		Id:          hex.EncodeToString(id),
+		AccountName: accountName,
+		L2Pk:        l2pk,
+	}
+	if file.Crypto, err = keystore.EncryptDataV3([]byte(seed), []byte(password), ks.ScryptN, ks.ScryptP); err != nil {
+		return err
+	}
+	if l1Key != nil {
+		l1Crypto, err := keystore.EncryptDataV3(crypto.FromECDSA(l1Key), []byte(password), ks.ScryptN, ks.ScryptP)
+		if err != nil {
+			return err
+		}
+		file.L1Crypto = &l1Crypto
+		file.Address = crypto.PubkeyToAddress(l1Key.PublicKey).Hex()
+	}
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so a failed write never loses the old key
+	dir := filepath.Dir(ks.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(ks.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if overwrite {
+		return os.Rename(tmp.Name(), ks.Path)
+	}
+	// unlike a rename, linking fails when a keystore was created meanwhile
+	defer os.Remove(tmp.Name())
+	if err := os.Link(tmp.Name(), ks.Path); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w: %s", ErrKeystoreExists, ks.Path)
+		}
+		return err
+	}
+	return nil
+}
+
+// KeystoreKeyManager signs with the keys decrypted from a Keystore.
+type KeystoreKeyManager struct {
+	key         KeyManager
+	accountName string
+	l2pk        string
+	l1Key       *ecdsa.PrivateKey
+}
+
+func newKeystoreKeyManager(accountName, seed string, l1Key *ecdsa.PrivateKey) (*KeystoreKeyManager, error) {
+	key, err := NewSeedKeyManager(seed)
+	if err != nil {
+		return nil, err
+	}
+	l2pk, err := l2pkOfSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &KeystoreKeyManager{key: key, accountName: accountName, l2pk: l2pk, l1Key: l1Key}, nil
+}
+
+func (km *KeystoreKeyManager) Sign(message []byte, hFunc hash.Hash) ([]byte, error) {
+	return km.key.Sign(message, hFunc)
+}
+
+func (km *KeystoreKeyManager) Public() signature.PublicKey {
+	return km.key.Public()
+}
+
+// AccountName returns the account name stored with the keys, if any.
+func (km *KeystoreKeyManager) AccountName() string {
+	return km.accountName
+}
+
+// L2Pk returns the hex encoded L2 public key.
+func (km *KeystoreKeyManager) L2Pk() string {
+	return km.l2pk
+}
+
+// L1Key returns the L1 private key, nil if the keystore holds only the seed.
+func (km *KeystoreKeyManager) L1Key() *ecdsa.PrivateKey {
+	return km.l1Key
+}
+
+// L1Address returns the L1 address, the zero address without L1 key.
+func (km *KeystoreKeyManager) L1Address() common.Address {
+	if km.l1Key == nil {
+		return common.Address{}
+	}
+	return crypto.PubkeyToAddress(km.l1Key.PublicKey)
+}
+
+// Close zeroes the L2 and L1 private keys.
+func (km *KeystoreKeyManager) Close() error {
+	if closer, ok := km.key.(io.Closer); ok {
+		closer.Close()
+	}
+	if km.l1Key != nil {
+		zeroKey(km.l1Key)
+		km.l1Key = nil
+	}
+	return nil
+}
+
+// zeroKey overwrites the secret of an ECDSA key in place.
+func zeroKey(key *ecdsa.PrivateKey) {
+	words := key.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	key.D.SetInt64(0)
+}
+
+func l2pkOfSeed(seed string) (string, error) {
+	if len(seed) < 3 {
+		return "", errors.New("invalid seed")
+	}
+	return eddsaHelper.GetEddsaPublicKey(seed[2:]), nil
+}
@@ -0,0 +1,115 @@
+package sdk
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+func TestKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zecrey-keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := &Keystore{Path: filepath.Join(dir, "alice.json"), ScryptN: keystore.LightScryptN, ScryptP: keystore.LightScryptP}
+
+	created, err := ks.Create("alice", "pass-1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.L1Key() == nil || created.L2Pk() == "" {
+		t.Fatal("created keystore misses keys")
+	}
+	if _, err := ks.Create("alice", "pass-1", true); !errors.Is(err, ErrKeystoreExists) {
+		t.Fatalf("expected ErrKeystoreExists, got %v", err)
+	}
+
+	content, err := ioutil.ReadFile(ks.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, l1PrivateKey, err := ks.Export("pass-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), seed) || strings.Contains(string(content), l1PrivateKey) {
+		t.Fatal("keystore holds plaintext keys")
+	}
+
+	if err := ks.ChangePassword("pass-1", "pass-2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Load("pass-1"); err == nil {
+		t.Fatal("old password still works")
+	}
+	loaded, err := ks.Load("pass-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.AccountName() != "alice" || loaded.L2Pk() != created.L2Pk() || loaded.L1Address() != created.L1Address() {
+		t.Fatal("loaded keys differ from the created ones")
+	}
+	msg := []byte("message")
+	sig1, err := created.Sign(msg, mimc.NewMiMC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, err := loaded.Sign(msg, mimc.NewMiMC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sig1) != string(sig2) {
+		t.Fatal("loaded key signs differently")
+	}
+
+	imported, err := (&Keystore{Path: filepath.Join(dir, "bob.json"), ScryptN: keystore.LightScryptN, ScryptP: keystore.LightScryptP}).
+		Import("bob", "", l1PrivateKey, "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.L2Pk() != created.L2Pk() {
+		t.Fatal("seed not derived from the imported l1 key")
+	}
+
+	// concurrent creates of one path leave exactly one keystore
+	racy := &Keystore{Path: filepath.Join(dir, "carol.json"), ScryptN: keystore.LightScryptN, ScryptP: keystore.LightScryptP}
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		winners []*KeystoreKeyManager
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			km, err := racy.Create("carol", "pass", false)
+			if err != nil {
+				if !errors.Is(err, ErrKeystoreExists) {
+					t.Error(err)
+				}
+				return
+			}
+			lock.Lock()
+			winners = append(winners, km)
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(winners) != 1 {
+		t.Fatalf("%d concurrent creates succeeded", len(winners))
+	}
+	if loaded, err := racy.Load("pass"); err != nil || loaded.L2Pk() != winners[0].L2Pk() {
+		t.Fatalf("stored keystore is not the winner's: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".carol.json.tmp*")); len(matches) != 0 {
+		t.Fatalf("temporary files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,152 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// L1Signer signs the L1 transactions of one address, e.g. the ZNS registration.
+type L1Signer interface {
+	Address() common.Address
+	// SignerFn returns the signer of the transactions of chainId, as used by
+	// bind.TransactOpts.
+	SignerFn(ctx context.Context, chainId *big.Int) (bind.SignerFn, error)
+}
+
+// L1SignerFunc adapts a bind.SignerFn, e.g. from bind.NewKeyStoreTransactorWithChainID,
+// to L1Signer. The function must sign for the chain the transactions are sent to.
+func L1SignerFunc(address common.Address, fn bind.SignerFn) L1Signer {
+	return &fnL1Signer{address: address, fn: fn}
+}
+
+type fnL1Signer struct {
+	address common.Address
+	fn      bind.SignerFn
+}
+
+func (s *fnL1Signer) Address() common.Address { return s.address }
+
+func (s *fnL1Signer) SignerFn(context.Context, *big.Int) (bind.SignerFn, error) {
+	return s.fn, nil
+}
+
+// NewPrivateKeyL1Signer signs with a raw private key, e.g. KeystoreKeyManager.L1Key.
+func NewPrivateKeyL1Signer(key *ecdsa.PrivateKey) (L1Signer, error) {
+	if key == nil {
+		return nil, errors.New("l1 private key is required")
+	}
+	return &keyL1Signer{key: key}, nil
+}
+
+type keyL1Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *keyL1Signer) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *keyL1Signer) SignerFn(_ context.Context, chainId *big.Int) (bind.SignerFn, error) {
+	opts, err := bind.NewKeyedTransactorWithChainID(s.key, chainId)
+	if err != nil {
+		return nil, err
+	}
+	return opts.Signer, nil
+}
+
+// NewAccountL1Signer signs with an account of a go-ethereum wallet, e.g. an
+// unlocked account of a keystore.KeyStore or a hardware wallet.
+func NewAccountL1Signer(wallet accounts.Wallet, account accounts.Account) L1Signer {
+	return &walletL1Signer{wallet: wallet, account: account}
+}
+
+// NewKeyStoreL1Signer signs with an unlocked account of a go-ethereum keystore directory.
+func NewKeyStoreL1Signer(ks *keystore.KeyStore, account accounts.Account) (L1Signer, error) {
+	found, err := ks.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	return &walletL1Signer{wallet: ks, account: found}, nil
+}
+
+// NewExternalL1Signer signs through a clef compatible external signer, e.g.
+// "http://localhost:8550" or the path of the clef ipc socket.
+func NewExternalL1Signer(endpoint string, address common.Address) (L1Signer, error) {
+	signer, err := external.NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("external signer: %v", err)
+	}
+	account := accounts.Account{Address: address}
+	if !signer.Contains(account) {
+		return nil, fmt.Errorf("external signer: unknown account %s", address.Hex())
+	}
+	return &walletL1Signer{wallet: signer, account: account}, nil
+}
+
+// txSigner is implemented by accounts.Wallet and keystore.KeyStore.
+type txSigner interface {
+	SignTx(account accounts.Account, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+}
+
+type walletL1Signer struct {
+	wallet  txSigner
+	account accounts.Account
+}
+
+func (s *walletL1Signer) Address() common.Address { return s.account.Address }
+
+func (s *walletL1Signer) SignerFn(_ context.Context, chainId *big.Int) (bind.SignerFn, error) {
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != s.account.Address {
+			return nil, bind.ErrNotAuthorized
+		}
+		return s.wallet.SignTx(s.account, tx, chainId)
+	}, nil
+}
+
+// L1Backend is the L1 chain the SDK sends transactions to: an rpc client, or
+// a SimulatedL1 in tests.
+type L1Backend interface {
+	bind.ContractBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// transactOpts returns the options of a transaction of signer on backend.
+func transactOpts(ctx context.Context, backend L1Backend, signer L1Signer, gasPrice *big.Int, gasLimit uint64) (*bind.TransactOpts, error) {
+	chainId, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signerFn, err := signer.SignerFn(ctx, chainId)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := backend.PendingNonceAt(ctx, signer.Address())
+	if err != nil {
+		return nil, err
+	}
+	if gasPrice == nil {
+		if gasPrice, err = backend.SuggestGasPrice(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &bind.TransactOpts{
+		From:     signer.Address(),
+		Signer:   signerFn,
+		Context:  ctx,
+		Nonce:    new(big.Int).SetUint64(nonce),
+		GasPrice: gasPrice,
+		GasLimit: gasLimit,
+	}, nil
+}
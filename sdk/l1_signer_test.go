@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	zecreyLegendRpc "github.com/zecrey-labs/zecrey-eth-rpc/zecrey/core/zecrey-legend"
+)
+
+func TestRegisterZNSWithL1Signer(t *testing.T) {
+	legendAbi, err := abi.JSON(strings.NewReader(zecreyLegendRpc.ZecreyLegendABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legend, oracle := common.HexToAddress("0x1001"), common.HexToAddress("0x1002")
+	price := big.NewInt(5000)
+	backend := NewSimulatedL1(97)
+	backend.SetContract(oracle, func(common.Address, []byte, *big.Int) ([]byte, error) {
+		return common.LeftPadBytes(price.Bytes(), 32), nil
+	})
+	var registered []string
+	backend.SetContract(legend, func(_ common.Address, data []byte, value *big.Int) ([]byte, error) {
+		args, err := legendAbi.Methods["registerZNS"].Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		registered = append(registered, args[0].(string))
+		return nil, nil
+	})
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := NewSeedKeyManager("0x" + strings.Repeat("12", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2pk := l2pkOfKey(seed)
+	keySigner, err := NewPrivateKeyL1Signer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(97))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fnSigner := L1SignerFunc(opts.From, opts.Signer)
+
+	dir, err := ioutil.TempDir("", "zecrey-l1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(account, "pass"); err != nil {
+		t.Fatal(err)
+	}
+	ksSigner, err := NewKeyStoreL1Signer(ks, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	backend.Fund(address, big.NewInt(1e18))
+	for i, signer := range []L1Signer{keySigner, fnSigner, ksSigner} {
+		if signer.Address() != address {
+			t.Fatalf("signer %d: unexpected address %s", i, signer.Address().Hex())
+		}
+		if _, err := RegisterZNS(context.Background(), backend, signer, legend, oracle, "alice", address, l2pk); err != nil {
+			t.Fatalf("signer %d: %v", i, err)
+		}
+	}
+	txs := backend.Transactions()
+	if len(txs) != 3 || len(registered) != 3 || registered[0] != "alice" {
+		t.Fatalf("unexpected registrations %v", registered)
+	}
+	if txs[2].Nonce() != 2 || txs[0].Value().Cmp(price) != 0 || backend.BalanceAt(legend).Cmp(big.NewInt(15000)) != 0 {
+		t.Fatal("registration not paid by the signer")
+	}
+
+	other, _ := crypto.GenerateKey()
+	otherSigner, _ := NewPrivateKeyL1Signer(other)
+	if _, err := RegisterZNS(context.Background(), backend, otherSigner, legend, oracle, "bob", address, l2pk); err == nil {
+		t.Fatal("unfunded signer registered")
+	}
+}
+
+func TestRegisterAccountConfig(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.LegendUrl = server.URL
+	cfg.ChainRpcUrl = server.URL
+	// the registration check runs against cfg, not the global config
+	if err := registerAccount(context.Background(), cfg, "alice", common.Address{}, nil, ""); err == nil {
+		t.Fatal("registration check did not fail")
+	}
+	if atomic.LoadInt32(&calls) == 0 {
+		t.Fatal("registration checked against another legend")
+	}
+}
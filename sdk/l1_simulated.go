@@ -0,0 +1,169 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SimulatedContract answers the calls and transactions sent to a contract of a
+// SimulatedL1. value is nil for calls.
+type SimulatedContract func(from common.Address, data []byte, value *big.Int) ([]byte, error)
+
+// SimulatedL1 is an in-memory L1Backend for tests. It checks the signature,
+// nonce and balance of every transaction and hands the calls to the contracts
+// set with SetContract; it does not run the EVM.
+type SimulatedL1 struct {
+	chainId  *big.Int
+	gasPrice *big.Int
+
+	lock      sync.Mutex
+	balances  map[common.Address]*big.Int
+	nonces    map[common.Address]uint64
+	contracts map[common.Address]SimulatedContract
+	txs       []*types.Transaction
+}
+
+func NewSimulatedL1(chainId int64) *SimulatedL1 {
+	return &SimulatedL1{
+		chainId:   big.NewInt(chainId),
+		gasPrice:  big.NewInt(1000000000),
+		balances:  make(map[common.Address]*big.Int),
+		nonces:    make(map[common.Address]uint64),
+		contracts: make(map[common.Address]SimulatedContract),
+	}
+}
+
+// Fund adds amount wei to the balance of address.
+func (b *SimulatedL1) Fund(address common.Address, amount *big.Int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.balances[address] = new(big.Int).Add(b.balanceOf(address), amount)
+}
+
+func (b *SimulatedL1) SetContract(address common.Address, contract SimulatedContract) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.contracts[address] = contract
+}
+
+func (b *SimulatedL1) BalanceAt(address common.Address) *big.Int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return new(big.Int).Set(b.balanceOf(address))
+}
+
+// Transactions returns the transactions sent so far.
+func (b *SimulatedL1) Transactions() []*types.Transaction {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]*types.Transaction(nil), b.txs...)
+}
+
+func (b *SimulatedL1) ChainID(context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.chainId), nil
+}
+
+func (b *SimulatedL1) CodeAt(_ context.Context, contract common.Address, _ *big.Int) ([]byte, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, ok := b.contracts[contract]; ok {
+		return []byte{0}, nil
+	}
+	return nil, nil
+}
+
+func (b *SimulatedL1) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	b.lock.Lock()
+	contract, ok := b.contracts[derefAddress(call.To)]
+	b.lock.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return contract(call.From, call.Data, nil)
+}
+
+func (b *SimulatedL1) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	// no BaseFee: bind sends legacy transactions
+	return &types.Header{Number: big.NewInt(int64(len(b.Transactions())))}, nil
+}
+
+func (b *SimulatedL1) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return b.CodeAt(ctx, account, nil)
+}
+
+func (b *SimulatedL1) PendingNonceAt(_ context.Context, account common.Address) (uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.nonces[account], nil
+}
+
+func (b *SimulatedL1) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.gasPrice), nil
+}
+
+func (b *SimulatedL1) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.gasPrice), nil
+}
+
+func (b *SimulatedL1) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return DefaultGasLimit, nil
+}
+
+// SendTransaction applies tx at once.
+func (b *SimulatedL1) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	from, err := types.Sender(types.LatestSignerForChainID(b.chainId), tx)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if nonce := b.nonces[from]; tx.Nonce() != nonce {
+		return fmt.Errorf("invalid nonce of %s: %d, expected %d", from.Hex(), tx.Nonce(), nonce)
+	}
+	cost := tx.Cost()
+	balance := b.balanceOf(from)
+	if balance.Cmp(cost) < 0 {
+		return fmt.Errorf("insufficient funds of %s: %s < %s", from.Hex(), balance, cost)
+	}
+	to := derefAddress(tx.To())
+	if contract, ok := b.contracts[to]; ok {
+		if _, err := contract(from, tx.Data(), tx.Value()); err != nil {
+			return err
+		}
+	}
+	// gas is charged in full, nothing is refunded
+	b.balances[from] = new(big.Int).Sub(balance, cost)
+	b.balances[to] = new(big.Int).Add(b.balanceOf(to), tx.Value())
+	b.nonces[from]++
+	b.txs = append(b.txs, tx)
+	return nil
+}
+
+func (b *SimulatedL1) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (b *SimulatedL1) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("simulated l1: log subscriptions are not supported")
+}
+
+func (b *SimulatedL1) balanceOf(address common.Address) *big.Int {
+	if balance, ok := b.balances[address]; ok {
+		return balance
+	}
+	return new(big.Int)
+}
+
+func derefAddress(address *common.Address) common.Address {
+	if address == nil {
+		return common.Address{}
+	}
+	return *address
+}
@@ -0,0 +1,391 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	zecreyLegendUtil "github.com/zecrey-labs/zecrey-legend/common/util"
+)
+
+// TxAssemblyMode selects who assembles the unsigned txs of the write operations.
+type TxAssemblyMode int
+
+const (
+	// TxAssemblyServer signs the txs prepared by the marketplace preparetx endpoints.
+	TxAssemblyServer TxAssemblyMode = iota
+	// TxAssemblyLocal assembles the txs from the legend, account and asset
+	// lookups, the preparetx endpoints are never called.
+	TxAssemblyLocal
+	// TxAssemblyCrossCheck assembles the txs locally and fails with
+	// ErrPreparedTxMismatch when the marketplace prepares another tx. The
+	// local tx is the one signed.
+	TxAssemblyCrossCheck
+)
+
+var (
+	ErrPreparedTxMismatch       = errors.New("prepared tx does not match the local tx")
+	ErrLocalAssemblyUnsupported = errors.New("operation can not be assembled locally")
+)
+
+// nftGasFeeAssetId is the asset the NFT txs prefer to pay their gas fee with.
+const nftGasFeeAssetId = 0
+
+// rateBase is the base of the creator and treasury rates, in basis points.
+var rateBase = big.NewInt(10000)
+
+// crossCheckIgnoredFields are set at assembly or signing time, so they differ
+// between two assemblies of the same tx. The gas fee is selected by the gas
+// fee policy after assembly.
+var crossCheckIgnoredFields = map[string]bool{
+	"ExpiredAt":         true,
+	"ListedAt":          true,
+	"GasAccountIndex":   true,
+	"GasFeeAssetId":     true,
+	"GasFeeAssetAmount": true,
+	"Sig":               true,
+}
+
+// assembleFunc assembles the unsigned tx of an operation locally.
+type assembleFunc func(ctx context.Context, cfg *Config) (SignableTx, error)
+
+// prepareTxInfo returns the unsigned tx of an operation, prepared by the
+// marketplace at preparePath or assembled by assemble as cfg.TxAssembly selects.
+func prepareTxInfo(ctx context.Context, cfg *Config, preparePath string, assemble assembleFunc) (txType int64, txInfo string, err error) {
+	if cfg.TxAssembly == TxAssemblyServer {
+		prepared, err := getPreparedTx(ctx, cfg, preparePath)
+		if err != nil {
+			return 0, "", err
+		}
+		return prepared.TxType, prepared.Transtion, nil
+	}
+	if assemble == nil {
+		return 0, "", ErrLocalAssemblyUnsupported
+	}
+	tx, err := assemble(ctx, cfg)
+	if err != nil {
+		return 0, "", err
+	}
+	if cfg.TxAssembly == TxAssemblyCrossCheck {
+		prepared, err := getPreparedTx(ctx, cfg, preparePath)
+		if err != nil {
+			return 0, "", err
+		}
+		if err := crossCheckTx(tx, prepared); err != nil {
+			return 0, "", err
+		}
+	}
+	b, err := json.Marshal(tx)
+	if err != nil {
+		return 0, "", err
+	}
+	return int64(tx.TxType()), string(b), nil
+}
+
+func getPreparedTx(ctx context.Context, cfg *Config, preparePath string) (*RespetPreparetxInfo, error) {
+	result := &RespetPreparetxInfo{}
+	if err := httpGet(ctx, cfg, cfg.NftMarketUrl+preparePath, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// crossCheckTx compares the tx prepared by the marketplace to the local tx,
+// field by field but for crossCheckIgnoredFields.
+func crossCheckTx(local SignableTx, prepared *RespetPreparetxInfo) error {
+	if prepared.TxType != int64(local.TxType()) {
+		return fmt.Errorf("%w: tx type %d, expected %d", ErrPreparedTxMismatch, prepared.TxType, local.TxType())
+	}
+	localFields, err := txFields(local)
+	if err != nil {
+		return err
+	}
+	preparedFields := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(prepared.Transtion))
+	decoder.UseNumber()
+	if err := decoder.Decode(&preparedFields); err != nil {
+		return fmt.Errorf("invalid prepared tx: %v", err)
+	}
+	if diff := diffTxFields("", localFields, preparedFields, nil); len(diff) > 0 {
+		return fmt.Errorf("%w: %s", ErrPreparedTxMismatch, strings.Join(diff, ", "))
+	}
+	return nil
+}
+
+func txFields(tx SignableTx) (map[string]interface{}, error) {
+	b, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func diffTxFields(prefix string, local, prepared map[string]interface{}, diff []string) []string {
+	var keys []string
+	for k := range local {
+		keys = append(keys, k)
+	}
+	for k := range prepared {
+		if _, ok := local[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if crossCheckIgnoredFields[k] {
+			continue
+		}
+		localMap, localIsMap := local[k].(map[string]interface{})
+		preparedMap, preparedIsMap := prepared[k].(map[string]interface{})
+		if localIsMap && preparedIsMap {
+			diff = diffTxFields(prefix+k+".", localMap, preparedMap, diff)
+			continue
+		}
+		if !reflect.DeepEqual(local[k], prepared[k]) {
+			diff = append(diff, prefix+k)
+		}
+	}
+	return diff
+}
+
+func assembleCreateCollectionTx(ctx context.Context, cfg *Config, accountName string) (*CreateCollectionTxInfo, error) {
+	accountIndex, err := getAccountIndex(ctx, cfg, accountName)
+	if err != nil {
+		return nil, err
+	}
+	collectionId, err := getMaxCollectionId(ctx, cfg, accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := getNextNonce(ctx, cfg, accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateCollectionTxInfo{
+		AccountIndex: accountIndex,
+		CollectionId: collectionId + 1,
+		ExpiredAt:    time.Now().Add(defaultTxExpiry).UnixMilli(),
+		Nonce:        nonce,
+	}, nil
+}
+
+func assembleMintNftTx(ctx context.Context, cfg *Config, accountName string, collectionId int64, contentHash string) (*MintNftTxInfo, error) {
+	accountIndex, err := getAccountIndex(ctx, cfg, accountName)
+	if err != nil {
+		return nil, err
+	}
+	accountNameHash, err := zecreyLegendUtil.ComputeAccountNameHash(accountName)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := getCollectionById(ctx, cfg, collectionId)
+	if err != nil {
+		return nil, err
+	}
+	nftIndex, err := getMaxNftIndex(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := getNextNonce(ctx, cfg, accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	return &MintNftTxInfo{
+		CreatorAccountIndex: accountIndex,
+		ToAccountIndex:      accountIndex,
+		ToAccountNameHash:   accountNameHash,
+		NftIndex:            nftIndex + 1,
+		NftContentHash:      contentHash,
+		NftCollectionId:     collection.Collection.L2CollectionId,
+		CreatorTreasuryRate: collection.Collection.CreatorEarningRate,
+		ExpiredAt:           time.Now().Add(defaultTxExpiry).UnixMilli(),
+		Nonce:               nonce,
+	}, nil
+}
+
+func assembleTransferNftTx(ctx context.Context, cfg *Config, accountName string, assetId int64, toAccountName string) (*TransferNftTxInfo, error) {
+	fromAccountIndex, err := getAccountIndex(ctx, cfg, accountName)
+	if err != nil {
+		return nil, err
+	}
+	toAccountIndex, err := getAccountIndex(ctx, cfg, toAccountName+NameSuffix)
+	if err != nil {
+		return nil, err
+	}
+	toAccountNameHash, err := zecreyLegendUtil.ComputeAccountNameHash(toAccountName + NameSuffix)
+	if err != nil {
+		return nil, err
+	}
+	nft, err := getNft(ctx, cfg, assetId)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := getNextNonce(ctx, cfg, fromAccountIndex)
+	if err != nil {
+		return nil, err
+	}
+	return &TransferNftTxInfo{
+		FromAccountIndex:  fromAccountIndex,
+		ToAccountIndex:    toAccountIndex,
+		ToAccountNameHash: toAccountNameHash,
+		NftIndex:          nft.NftIndex,
+		CallDataHash:      callDataHash(""),
+		ExpiredAt:         time.Now().Add(defaultTxExpiry).UnixMilli(),
+		Nonce:             nonce,
+	}, nil
+}
+
+func assembleOfferTx(ctx context.Context, cfg *Config, accountName string, assetId int64, paymentAssetId int64, amount *big.Int, isSell bool) (*OfferTxInfo, error) {
+	if cfg.OfferTreasuryRate == 0 {
+		return nil, fmt.Errorf("config: offer treasury rate is required to assemble offers locally")
+	}
+	accountIndex, err := getAccountIndex(ctx, cfg, accountName)
+	if err != nil {
+		return nil, err
+	}
+	nft, err := getNft(ctx, cfg, assetId)
+	if err != nil {
+		return nil, err
+	}
+	offerId, err := getNextOfferId(ctx, cfg, accountName)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tx := &OfferTxInfo{
+		OfferId:      offerId.Id,
+		AccountIndex: accountIndex,
+		NftIndex:     nft.NftIndex,
+		AssetId:      paymentAssetId,
+		AssetAmount:  amount,
+		ListedAt:     now.UnixMilli(),
+		ExpiredAt:    now.Add(defaultTxExpiry).UnixMilli(),
+		TreasuryRate: cfg.OfferTreasuryRate,
+	}
+	if isSell {
+		tx.Type = 1
+	}
+	return tx, nil
+}
+
+// assembleAtomicMatchTx matches the offer offerId, whose signature is
+// verified, with a new offer of accountName.
+func assembleAtomicMatchTx(ctx context.Context, cfg *Config, accountName string, offerId int64, isSell bool, amount *big.Int) (*AtomicMatchTxInfo, error) {
+	offer, err := getOfferById(ctx, cfg, offerId)
+	if err != nil {
+		return nil, err
+	}
+	counter, err := verifyOffer(ctx, cfg, &offer.Offer)
+	if err != nil {
+		return nil, err
+	}
+	if (counter.Type == 1) == isSell {
+		return nil, fmt.Errorf("offer %d can not be accepted by an offer of the same side", offerId)
+	}
+	own, err := assembleOfferTx(ctx, cfg, accountName, offer.Offer.AssetId, counter.AssetId, amount, isSell)
+	if err != nil {
+		return nil, err
+	}
+	if own.NftIndex != counter.NftIndex {
+		return nil, fmt.Errorf("offer %d is not an offer of asset %d", offerId, offer.Offer.AssetId)
+	}
+	own.TreasuryRate = counter.TreasuryRate
+	nft, err := getNft(ctx, cfg, offer.Offer.AssetId)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := getNextNonce(ctx, cfg, own.AccountIndex)
+	if err != nil {
+		return nil, err
+	}
+	tx := &AtomicMatchTxInfo{
+		AccountIndex: own.AccountIndex,
+		BuyOffer:     counter,
+		SellOffer:    own,
+		Nonce:        nonce,
+		ExpiredAt:    time.Now().Add(defaultTxExpiry).UnixMilli(),
+	}
+	if !isSell {
+		tx.BuyOffer, tx.SellOffer = own, counter
+	}
+	price := tx.SellOffer.AssetAmount
+	tx.CreatorAmount = new(big.Int).Div(new(big.Int).Mul(price, big.NewInt(nft.CreatorEarningRate)), rateBase)
+	tx.TreasuryAmount = new(big.Int).Div(new(big.Int).Mul(price, big.NewInt(tx.SellOffer.TreasuryRate)), rateBase)
+	return tx, nil
+}
+
+// assembleCancelOfferTx cancels the offer offerId of accountName, paying the
+// gas fee in the payment asset of the offer when possible.
+func assembleCancelOfferTx(ctx context.Context, cfg *Config, accountName string, offerId int64) (*CancelOfferTxInfo, error) {
+	offer, err := getOfferById(ctx, cfg, offerId)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := ParseOfferTxInfo(offer.Offer.Signature)
+	if err != nil || signed == nil {
+		return nil, fmt.Errorf("offer %d has no signed offer: %v", offerId, err)
+	}
+	accountIndex, err := getAccountIndex(ctx, cfg, accountName)
+	if err != nil {
+		return nil, err
+	}
+	if signed.AccountIndex != accountIndex {
+		return nil, fmt.Errorf("offer %d is not an offer of %s", offerId, accountName)
+	}
+	fee, err := selectGasFee(ctx, cfg, TxTypeCancelOffer, offer.Offer.PaymentAssetId)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := getNextNonce(ctx, cfg, accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	tx := &CancelOfferTxInfo{
+		AccountIndex: accountIndex,
+		OfferId:      signed.OfferId,
+		Nonce:        nonce,
+		ExpiredAt:    time.Now().Add(defaultTxExpiry).UnixMilli(),
+	}
+	tx.setGasFee(fee)
+	return tx, nil
+}
+
+func getNft(ctx context.Context, cfg *Config, assetId int64) (*NftInfo, error) {
+	result, err := getNftById(ctx, cfg, assetId)
+	if err != nil {
+		return nil, err
+	}
+	if result.Asset == nil {
+		return nil, fmt.Errorf("asset %d not found", assetId)
+	}
+	return result.Asset, nil
+}
+
+func getMaxCollectionId(ctx context.Context, cfg *Config, accountIndex int64) (int64, error) {
+	result := &RespGetMaxCollectionId{}
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+fmt.Sprintf("/api/v1/nft/getMaxCollectionId?account_index=%d", accountIndex), result); err != nil {
+		return 0, err
+	}
+	return result.CollectionId, nil
+}
+
+func getMaxNftIndex(ctx context.Context, cfg *Config) (int64, error) {
+	result := &RespGetMaxNftIndex{}
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+"/api/v1/nft/getMaxNftIndex", result); err != nil {
+		return 0, err
+	}
+	return result.MaxNftIndex, nil
+}
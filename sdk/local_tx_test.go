@@ -0,0 +1,163 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	zecreyLegendUtil "github.com/zecrey-labs/zecrey-legend/common/util"
+)
+
+func TestLocalTxAssembly(t *testing.T) {
+	nameHash, _ := zecreyLegendUtil.ComputeAccountNameHash("bob" + NameSuffix)
+	prepared := &TransferNftTxInfo{
+		FromAccountIndex:  1,
+		ToAccountIndex:    2,
+		ToAccountNameHash: nameHash,
+		NftIndex:          7,
+		GasAccountIndex:   9,
+		CallDataHash:      callDataHash(""),
+		Nonce:             3,
+		ExpiredAt:         1,
+	}
+	prepareCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/account/getAccountByAccountName":
+			index := map[string]int64{"alice.zec": 1, "bob.zec": 2}[r.URL.Query().Get("account_name")]
+			fmt.Fprintf(w, `{"account": {"account_index": %d}}`, index)
+		case "/api/v1/action/actionGetAssetByAssetId":
+			w.Write([]byte(`{"asset": {"id": 12, "nft_index": 7}}`))
+		case "/api/v1/offer/getNextOfferId":
+			w.Write([]byte(`{"id": 5}`))
+		case "/api/v1/info/getGasAccount":
+			w.Write([]byte(`{"account_index": 9}`))
+		case "/api/v1/info/getGasFeeAssetList":
+			w.Write([]byte(`{"assets": [{"asset_id": 0}]}`))
+		case "/api/v1/info/getGasFee":
+			w.Write([]byte(`{"gas_fee": "100"}`))
+		case "/api/v1/tx/getNextNonce":
+			w.Write([]byte(`{"nonce": 3}`))
+		case "/api/v1/preparetx/getPrepareTransferNftTxInfo":
+			prepareCalls++
+			txInfo, _ := json.Marshal(prepared)
+			json.NewEncoder(w).Encode(&RespetPreparetxInfo{TxType: TxTypeTransferNft, Transtion: string(txInfo)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	cfg.LegendUrl = server.URL
+	cfg.TxAssembly = TxAssemblyLocal
+	ctx := context.Background()
+
+	env, err := prepareTransferNft(ctx, cfg, "alice.zec", 12, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &TransferNftTxInfo{}
+	if err := json.Unmarshal([]byte(env.TxInfo), tx); err != nil {
+		t.Fatal(err)
+	}
+	if prepareCalls != 0 || env.TxType != TxTypeTransferNft || env.ExpiredAt != tx.ExpiredAt ||
+		tx.FromAccountIndex != 1 || tx.ToAccountIndex != 2 || tx.ToAccountNameHash != nameHash || tx.NftIndex != 7 || tx.Nonce != 3 {
+		t.Fatalf("unexpected local tx %+v", tx)
+	}
+	if _, err := prepareOffer(ctx, cfg, "alice.zec", 12, 0, big.NewInt(1000), true); err == nil {
+		t.Fatal("offer assembled without a treasury rate")
+	}
+	cfg.OfferTreasuryRate = 250
+	env, err = prepareOffer(ctx, cfg, "alice.zec", 12, 0, big.NewInt(1000), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offer, err := ParseOfferTxInfo(env.TxInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offer.Type != 1 || offer.OfferId != 5 || offer.NftIndex != 7 || offer.TreasuryRate != 250 || offer.AssetAmount.Int64() != 1000 {
+		t.Fatalf("unexpected local offer %+v", offer)
+	}
+	if _, err := prepareWithdrawNft(ctx, cfg, "alice.zec", 12); !errors.Is(err, ErrLocalAssemblyUnsupported) {
+		t.Fatalf("withdraw nft assembled locally: %v", err)
+	}
+
+	cfg.TxAssembly = TxAssemblyCrossCheck
+	if _, err := prepareTransferNft(ctx, cfg, "alice.zec", 12, "bob"); err != nil || prepareCalls != 1 {
+		t.Fatalf("matching prepared tx rejected: %v", err)
+	}
+	prepared.ToAccountIndex = 3
+	_, err = prepareTransferNft(ctx, cfg, "alice.zec", 12, "bob")
+	if !errors.Is(err, ErrPreparedTxMismatch) || !strings.Contains(err.Error(), "ToAccountIndex") {
+		t.Fatalf("tampered prepared tx accepted: %v", err)
+	}
+}
+
+func TestLocalTxAssemblyNonces(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	nonces := map[int64]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/account/getAccountByAccountName":
+			index := map[string]int64{"alice.zec": 1, "bob.zec": 2}[r.URL.Query().Get("account_name")]
+			fmt.Fprintf(w, `{"account": {"account_index": %d}}`, index)
+		case "/api/v1/action/actionGetAssetByAssetId":
+			w.Write([]byte(`{"asset": {"id": 12, "nft_index": 7}}`))
+		case "/api/v1/info/getGasAccount":
+			w.Write([]byte(`{"account_index": 9}`))
+		case "/api/v1/info/getGasFeeAssetList":
+			w.Write([]byte(`{"assets": [{"asset_id": 0}]}`))
+		case "/api/v1/info/getGasFee":
+			w.Write([]byte(`{"gas_fee": "100"}`))
+		case "/api/v1/tx/getNextNonce":
+			mu.Lock()
+			fmt.Fprintf(w, `{"nonce": %d}`, len(nonces))
+			mu.Unlock()
+		case "/api/v1/asset/sendTransferNft":
+			tx := &TransferNftTxInfo{}
+			json.Unmarshal([]byte(r.FormValue("transaction")), tx)
+			mu.Lock()
+			nonces[tx.Nonce] = true
+			mu.Unlock()
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	cfg.LegendUrl = server.URL
+	cfg.TxAssembly = TxAssemblyLocal
+	c := newClientWithKeyManager(cfg, nil, "alice.zec", key, l2pkOfKey(key))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.TransferNft(12, "bob"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(nonces) != 5 {
+		t.Fatalf("concurrent txs signed %d distinct nonces, want 5", len(nonces))
+	}
+}
@@ -0,0 +1,228 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Logger is a leveled, structured logger. keysAndValues are alternating
+// keys and values, e.g. logger.Info("request done", "endpoint", path, "status", 200).
+// The SDK redacts the values of secret keys such as seeds, signatures and
+// hasura keys before they reach the Logger.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// NopLogger discards everything, it is the default of every config.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// WithLevel drops the entries of logger below level.
+func WithLevel(logger Logger, level LogLevel) Logger {
+	return &levelLogger{logger: logger, level: level}
+}
+
+type levelLogger struct {
+	logger Logger
+	level  LogLevel
+}
+
+func (l *levelLogger) Debug(msg string, kv ...interface{}) {
+	if l.level <= LevelDebug {
+		l.logger.Debug(msg, kv...)
+	}
+}
+
+func (l *levelLogger) Info(msg string, kv ...interface{}) {
+	if l.level <= LevelInfo {
+		l.logger.Info(msg, kv...)
+	}
+}
+
+func (l *levelLogger) Warn(msg string, kv ...interface{}) {
+	if l.level <= LevelWarn {
+		l.logger.Warn(msg, kv...)
+	}
+}
+
+func (l *levelLogger) Error(msg string, kv ...interface{}) {
+	l.logger.Error(msg, kv...)
+}
+
+// NewLogxLogger logs through go-zero logx. logx has no debug and warn
+// levels, they are written as info entries prefixed with the level.
+func NewLogxLogger(level LogLevel) Logger {
+	return WithLevel(logxLogger{}, level)
+}
+
+type logxLogger struct{}
+
+func (logxLogger) Debug(msg string, kv ...interface{}) {
+	logx.Info("[debug] " + formatEntry(msg, kv))
+}
+
+func (logxLogger) Info(msg string, kv ...interface{}) {
+	logx.Info(formatEntry(msg, kv))
+}
+
+func (logxLogger) Warn(msg string, kv ...interface{}) {
+	logx.Info("[warn] " + formatEntry(msg, kv))
+}
+
+func (logxLogger) Error(msg string, kv ...interface{}) {
+	logx.Error(formatEntry(msg, kv))
+}
+
+// ZapSugaredLogger is the subset of *zap.SugaredLogger used by NewZapLogger,
+// so that the SDK does not depend on zap.
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// NewZapLogger adapts a zap logger, e.g. NewZapLogger(zapLogger.Sugar()).
+func NewZapLogger(logger ZapSugaredLogger) Logger {
+	return zapLogger{logger: logger}
+}
+
+type zapLogger struct {
+	logger ZapSugaredLogger
+}
+
+func (l zapLogger) Debug(msg string, kv ...interface{}) { l.logger.Debugw(msg, kv...) }
+func (l zapLogger) Info(msg string, kv ...interface{})  { l.logger.Infow(msg, kv...) }
+func (l zapLogger) Warn(msg string, kv ...interface{})  { l.logger.Warnw(msg, kv...) }
+func (l zapLogger) Error(msg string, kv ...interface{}) { l.logger.Errorw(msg, kv...) }
+
+func formatEntry(msg string, kv []interface{}) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(kv); i += 2 {
+		b.WriteString(" ")
+		if i+1 < len(kv) {
+			fmt.Fprintf(&b, "%v=%v", kv[i], kv[i+1])
+		} else {
+			fmt.Fprintf(&b, "%v", kv[i])
+		}
+	}
+	return b.String()
+}
+
+const redacted = "[REDACTED]"
+
+var secretKeys = []string{"seed", "signature", "private", "secret", "password", "mnemonic", "admin_key", "adminkey", "access_key", "accesskey"}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(strings.NewReplacer("-", "_", " ", "_").Replace(key))
+	if key == "sig" || key == "sk" {
+		return true
+	}
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactingLogger replaces the values of secret keys, and any occurrence of
+// the known secrets in messages and values, before calling logger.
+type redactingLogger struct {
+	logger  Logger
+	secrets []string
+}
+
+func (l redactingLogger) Debug(msg string, kv ...interface{}) {
+	l.logger.Debug(l.redactString(msg), l.redact(kv)...)
+}
+
+func (l redactingLogger) Info(msg string, kv ...interface{}) {
+	l.logger.Info(l.redactString(msg), l.redact(kv)...)
+}
+
+func (l redactingLogger) Warn(msg string, kv ...interface{}) {
+	l.logger.Warn(l.redactString(msg), l.redact(kv)...)
+}
+
+func (l redactingLogger) Error(msg string, kv ...interface{}) {
+	l.logger.Error(l.redactString(msg), l.redact(kv)...)
+}
+
+func (l redactingLogger) redact(kv []interface{}) []interface{} {
+	out := make([]interface{}, len(kv))
+	copy(out, kv)
+	for i := 0; i+1 < len(out); i += 2 {
+		if key, ok := out[i].(string); ok && isSecretKey(key) {
+			out[i+1] = redacted
+			continue
+		}
+		switch v := out[i+1].(type) {
+		case string:
+			out[i+1] = l.redactString(v)
+		case error:
+			if s := l.redactString(v.Error()); s != v.Error() {
+				out[i+1] = s
+			}
+		}
+	}
+	return out
+}
+
+func (l redactingLogger) redactString(s string) string {
+	for _, secret := range l.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// logger returns the config logger, redacting the hasura key and the given secrets.
+func (cfg *Config) logger(secrets ...string) Logger {
+	if cfg.Logger == nil {
+		return nopLogger{}
+	}
+	l := redactingLogger{logger: cfg.Logger}
+	for _, secret := range append(secrets, cfg.HasuraAdminKey) {
+		if secret != "" {
+			l.secrets = append(l.secrets, secret)
+		}
+	}
+	return l
+}
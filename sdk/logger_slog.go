@@ -0,0 +1,34 @@
+//go:build go1.21
+// +build go1.21
+
+package sdk
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger adapts a log/slog logger.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Debug(msg string, kv ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelDebug, msg, kv...)
+}
+
+func (l slogLogger) Info(msg string, kv ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelInfo, msg, kv...)
+}
+
+func (l slogLogger) Warn(msg string, kv ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelWarn, msg, kv...)
+}
+
+func (l slogLogger) Error(msg string, kv ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelError, msg, kv...)
+}
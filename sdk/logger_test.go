@@ -0,0 +1,56 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordLogger struct {
+	entries []string
+}
+
+func (l *recordLogger) log(level, msg string, kv []interface{}) {
+	l.entries = append(l.entries, level+" "+formatEntry(msg, kv))
+}
+
+func (l *recordLogger) Debug(msg string, kv ...interface{}) { l.log("debug", msg, kv) }
+func (l *recordLogger) Info(msg string, kv ...interface{})  { l.log("info", msg, kv) }
+func (l *recordLogger) Warn(msg string, kv ...interface{})  { l.log("warn", msg, kv) }
+func (l *recordLogger) Error(msg string, kv ...interface{}) { l.log("error", msg, kv) }
+
+func TestLoggerRedaction(t *testing.T) {
+	record := &recordLogger{}
+	cfg := DefaultConfig()
+	cfg.HasuraAdminKey = "hasura-key"
+	cfg.Logger = WithLevel(record, LevelInfo)
+
+	logger := cfg.logger("0xseed")
+	logger.Debug("dropped")
+	logger.Info("signed", "seed", "0xseed", "signature", "abcd", "err", fmt.Errorf("bad key hasura-key"), "memo", "seed 0xseed")
+	if len(record.entries) != 1 {
+		t.Fatalf("unexpected entries: %v", record.entries)
+	}
+	entry := record.entries[0]
+	for _, secret := range []string{"0xseed", "abcd", "hasura-key"} {
+		if strings.Contains(entry, secret) {
+			t.Fatalf("secret %s not redacted: %s", secret, entry)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"nonce": 1}`))
+	}))
+	defer server.Close()
+	record.entries = nil
+	cfg.Logger = record
+	if err := httpGet(context.Background(), cfg, server.URL+"/api/v1/tx/getNextNonce", &RespGetNextNonce{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(record.entries) != 1 || !strings.Contains(record.entries[0], "endpoint=/api/v1/tx/getNextNonce") {
+		t.Fatalf("unexpected entries: %v", record.entries)
+	}
+}
@@ -0,0 +1,388 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/Zecrey-Labs/zecrey-marketplace-go-sdk/sdk/model"
+)
+
+// EnvelopeVersion is the version of the envelope file format.
+const EnvelopeVersion = 1
+
+// The operations an Envelope can carry.
+const (
+	OpCreateCollection = "CreateCollection"
+	OpMintNft          = "MintNft"
+	OpTransferNft      = "TransferNft"
+	OpWithdrawNft      = "WithdrawNft"
+	OpCreateSellOffer  = "CreateSellOffer"
+	OpCreateBuyOffer   = "CreateBuyOffer"
+	OpAcceptOffer      = "AcceptOffer"
+)
+
+var (
+	ErrEnvelopeExpired  = errors.New("envelope expired")
+	ErrEnvelopeUnsigned = errors.New("envelope is not signed")
+)
+
+// Envelope carries an operation through the air-gapped workflow: it is
+// prepared online, signed offline by the host holding the KeyManager and
+// submitted online again. Every step reads and writes the same JSON file.
+type Envelope struct {
+	Version     int    `json:"version"`
+	Operation   string `json:"operation"`
+	AccountName string `json:"account_name"`
+	TxType      int64  `json:"tx_type"`
+	// TxInfo is the unsigned tx info returned by the preparetx endpoint, or
+	// assembled locally as selected by Config.TxAssembly.
+	TxInfo string `json:"tx_info"`
+	// ExpiredAt, of TxInfo, and CreatedAt are in unix milliseconds.
+	ExpiredAt int64 `json:"expired_at"`
+	CreatedAt int64 `json:"created_at"`
+	// Description, IsSell and AssetAmount complete TxInfo when signing.
+	Description string `json:"description,omitempty"`
+	IsSell      bool   `json:"is_sell,omitempty"`
+	AssetAmount string `json:"asset_amount,omitempty"`
+	// SubmitPath and Form are where and what to post once signed, the signed
+	// tx is sent as the "transaction" field.
+	SubmitPath string     `json:"submit_path"`
+	Form       url.Values `json:"form"`
+	SignedTx   string     `json:"signed_tx,omitempty"`
+	// GasFee is the gas fee set in TxInfo when prepared, and the one signed
+	// once signed. Offers pay no gas fee.
+	GasFee *GasFee `json:"gas_fee,omitempty"`
+}
+
+func (env *Envelope) Signed() bool {
+	return env.SignedTx != ""
+}
+
+func (env *Envelope) Expired(now time.Time) bool {
+	return env.ExpiredAt > 0 && now.UnixNano()/int64(time.Millisecond) > env.ExpiredAt
+}
+
+// ReadEnvelope reads an envelope written by WriteEnvelope.
+func ReadEnvelope(path string) (*Envelope, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	env := &Envelope{}
+	if err := json.Unmarshal(content, env); err != nil {
+		return nil, fmt.Errorf("invalid envelope %s: %v", path, err)
+	}
+	if env.Version != EnvelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version %d", env.Version)
+	}
+	return env, nil
+}
+
+func WriteEnvelope(path string, env *Envelope) error {
+	content, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0600)
+}
+
+func PrepareCreateCollectionEnvelope(accountName string, ShortName string, CategoryId string, CreatorEarningRate string, ops ...model.CollectionOption) (*Envelope, error) {
+	return PrepareCreateCollectionEnvelopeWithContext(context.Background(), accountName, ShortName, CategoryId, CreatorEarningRate, ops...)
+}
+
+func PrepareCreateCollectionEnvelopeWithContext(ctx context.Context, accountName string, ShortName string, CategoryId string, CreatorEarningRate string, ops ...model.CollectionOption) (*Envelope, error) {
+	return prepareCreateCollection(ctx, GetConfig(), accountName, ShortName, CategoryId, CreatorEarningRate, ops...)
+}
+
+func PrepareMintNftEnvelope(accountName string, CollectionId int64, NftUrl string, Name string, Description string, Media string, Properties string, Levels string, Stats string) (*Envelope, error) {
+	return PrepareMintNftEnvelopeWithContext(context.Background(), accountName, CollectionId, NftUrl, Name, Description, Media, Properties, Levels, Stats)
+}
+
+func PrepareMintNftEnvelopeWithContext(ctx context.Context, accountName string, CollectionId int64, NftUrl string, Name string, Description string, Media string, Properties string, Levels string, Stats string) (*Envelope, error) {
+	return prepareMintNft(ctx, GetConfig(), accountName, CollectionId, NftUrl, Name, Description, Media, Properties, Levels, Stats)
+}
+
+func PrepareTransferNftEnvelope(accountName string, AssetId int64, toAccountName string) (*Envelope, error) {
+	return PrepareTransferNftEnvelopeWithContext(context.Background(), accountName, AssetId, toAccountName)
+}
+
+func PrepareTransferNftEnvelopeWithContext(ctx context.Context, accountName string, AssetId int64, toAccountName string) (*Envelope, error) {
+	return prepareTransferNft(ctx, GetConfig(), accountName, AssetId, toAccountName)
+}
+
+func PrepareWithdrawNftEnvelope(accountName string, AssetId int64) (*Envelope, error) {
+	return PrepareWithdrawNftEnvelopeWithContext(context.Background(), accountName, AssetId)
+}
+
+func PrepareWithdrawNftEnvelopeWithContext(ctx context.Context, accountName string, AssetId int64) (*Envelope, error) {
+	return prepareWithdrawNft(ctx, GetConfig(), accountName, AssetId)
+}
+
+func PrepareOfferEnvelope(accountName string, AssetId int64, AssetType int64, AssetAmount *big.Int, isSell bool) (*Envelope, error) {
+	return PrepareOfferEnvelopeWithContext(context.Background(), accountName, AssetId, AssetType, AssetAmount, isSell)
+}
+
+func PrepareOfferEnvelopeWithContext(ctx context.Context, accountName string, AssetId int64, AssetType int64, AssetAmount *big.Int, isSell bool) (*Envelope, error) {
+	return prepareOffer(ctx, GetConfig(), accountName, AssetId, AssetType, AssetAmount, isSell)
+}
+
+func PrepareAcceptOfferEnvelope(accountName string, offerId int64, isSell bool, AssetAmount *big.Int) (*Envelope, error) {
+	return PrepareAcceptOfferEnvelopeWithContext(context.Background(), accountName, offerId, isSell, AssetAmount)
+}
+
+func PrepareAcceptOfferEnvelopeWithContext(ctx context.Context, accountName string, offerId int64, isSell bool, AssetAmount *big.Int) (*Envelope, error) {
+	return prepareAcceptOffer(ctx, GetConfig(), accountName, offerId, isSell, AssetAmount)
+}
+
+// SignEnvelope signs the tx of env with key. It needs no network access.
+func SignEnvelope(key KeyManager, env *Envelope) error {
+	return SignEnvelopeWithContext(context.Background(), key, env)
+}
+
+func SignEnvelopeWithContext(ctx context.Context, key KeyManager, env *Envelope) error {
+	if env.Signed() {
+		return errors.New("envelope is already signed")
+	}
+	if env.Expired(time.Now()) {
+		return ErrEnvelopeExpired
+	}
+	key = withContext(ctx, key)
+	var (
+		tx  string
+		err error
+	)
+	switch env.Operation {
+	case OpCreateCollection:
+		tx, err = PrepareCreateCollectionTxInfo(key, env.TxInfo, env.Description)
+	case OpMintNft:
+		tx, err = PrepareMintNftTxInfo(key, env.TxInfo)
+	case OpTransferNft:
+		tx, err = PrepareTransferNftTxInfo(key, env.TxInfo)
+	case OpWithdrawNft:
+		tx, err = PrepareWithdrawNftTxInfo(key, env.TxInfo)
+	case OpCreateSellOffer, OpCreateBuyOffer:
+		tx, err = PrepareOfferTxInfo(key, env.TxInfo, env.IsSell)
+	case OpAcceptOffer:
+		amount, ok := new(big.Int).SetString(env.AssetAmount, 10)
+		if !ok {
+			return fmt.Errorf("invalid asset amount %q", env.AssetAmount)
+		}
+		tx, err = PrepareAtomicMatchWithTx(key, env.TxInfo, env.IsSell, amount)
+	default:
+		return fmt.Errorf("unknown operation %q", env.Operation)
+	}
+	if err != nil {
+		return err
+	}
+	env.SignedTx = tx
+	if signed := newFeeTx(env.Operation); signed != nil {
+		if err := json.Unmarshal([]byte(tx), signed); err != nil {
+			return err
+		}
+		env.GasFee = signed.gasFee()
+	}
+	return nil
+}
+
+// SubmitEnvelope sends the signed tx of env and decodes the response of the
+// marketplace into result, e.g. a *RespCreateAsset for OpMintNft.
+func SubmitEnvelope(env *Envelope, result interface{}) error {
+	return SubmitEnvelopeWithContext(context.Background(), env, result)
+}
+
+func SubmitEnvelopeWithContext(ctx context.Context, env *Envelope, result interface{}) error {
+	return submitEnvelope(ctx, GetConfig(), env, result)
+}
+
+func prepareCreateCollection(ctx context.Context, cfg *Config, accountName string, ShortName string, CategoryId string, CreatorEarningRate string, ops ...model.CollectionOption) (*Envelope, error) {
+	cp := &model.CollectionParams{}
+	for _, do := range ops {
+		do.F(cp)
+	}
+	env, err := prepareEnvelope(ctx, cfg, OpCreateCollection, accountName,
+		fmt.Sprintf("/api/v1/preparetx/getPrepareCreateCollectionTxInfo?account_name=%s", accountName),
+		"/api/v1/collection/createCollection",
+		url.Values{"short_name": {ShortName},
+			"category_id":          {CategoryId},
+			"collection_url":       {cp.CollectionUrl},
+			"external_link":        {cp.ExternalLink},
+			"twitter_link":         {cp.TwitterLink},
+			"instagram_link":       {cp.TelegramLink},
+			"discord_link":         {cp.InstagramLink},
+			"telegram_link":        {cp.DiscordLink},
+			"logo_image":           {cp.LogoImage},
+			"featured_image":       {cp.FeaturedImage},
+			"banner_image":         {cp.BannerImage},
+			"creator_earning_rate": {CreatorEarningRate},
+			"payment_asset_ids":    {cp.PaymentAssetIds}},
+		func(ctx context.Context, cfg *Config) (SignableTx, error) {
+			return assembleCreateCollectionTx(ctx, cfg, accountName)
+		})
+	if err != nil {
+		return nil, err
+	}
+	env.Description = cp.Description
+	return env, nil
+}
+
+func prepareMintNft(ctx context.Context, cfg *Config, accountName string, CollectionId int64, NftUrl string, Name string, Description string, Media string, Properties string, Levels string, Stats string) (*Envelope, error) {
+	ContentHash, err := calculateContentHash(accountName, CollectionId, Name, Properties, Levels, Stats)
+	if err != nil {
+		return nil, err
+	}
+	cfg.logger().Debug("nft content hash", "collection_id", CollectionId, "name", Name, "content_hash", ContentHash)
+	return prepareEnvelope(ctx, cfg, OpMintNft, accountName,
+		fmt.Sprintf("/api/v1/preparetx/getPrepareMintNftTxInfo?account_name=%s&collection_id=%d&name=%s&content_hash=%s", accountName, CollectionId, Name, ContentHash),
+		"/api/v1/asset/createAsset",
+		url.Values{
+			"collection_id": {fmt.Sprintf("%d", CollectionId)},
+			"nft_url":       {NftUrl},
+			"name":          {Name},
+			"description":   {Description},
+			"media":         {Media},
+			"properties":    {Properties},
+			"levels":        {Levels},
+			"stats":         {Stats},
+		},
+		func(ctx context.Context, cfg *Config) (SignableTx, error) {
+			return assembleMintNftTx(ctx, cfg, accountName, CollectionId, ContentHash)
+		})
+}
+
+func prepareTransferNft(ctx context.Context, cfg *Config, accountName string, AssetId int64, toAccountName string) (*Envelope, error) {
+	return prepareEnvelope(ctx, cfg, OpTransferNft, accountName,
+		fmt.Sprintf("/api/v1/preparetx/getPrepareTransferNftTxInfo?account_name=%s&to_account_name=%s%s&nft_id=%d", accountName, toAccountName, NameSuffix, AssetId),
+		"/api/v1/asset/sendTransferNft",
+		url.Values{"asset_id": {fmt.Sprintf("%d", AssetId)}},
+		func(ctx context.Context, cfg *Config) (SignableTx, error) {
+			return assembleTransferNftTx(ctx, cfg, accountName, AssetId, toAccountName)
+		})
+}
+
+func prepareWithdrawNft(ctx context.Context, cfg *Config, accountName string, AssetId int64) (*Envelope, error) {
+	return prepareEnvelope(ctx, cfg, OpWithdrawNft, accountName,
+		fmt.Sprintf("/api/v1/preparetx/getPrepareWithdrawNftTxInfo?account_name=%s&nft_id=%d", accountName, AssetId),
+		"/api/v1/asset/sendWithdrawNft",
+		url.Values{"asset_id": {fmt.Sprintf("%d", AssetId)}},
+		// the L1 details of the NFT are only known to the marketplace
+		nil)
+}
+
+func prepareOffer(ctx context.Context, cfg *Config, accountName string, AssetId int64, AssetType int64, AssetAmount *big.Int, isSell bool) (*Envelope, error) {
+	op := OpCreateBuyOffer
+	if isSell {
+		op = OpCreateSellOffer
+	}
+	env, err := prepareEnvelope(ctx, cfg, op, accountName,
+		fmt.Sprintf("/api/v1/preparetx/getPrepareOfferTxInfo?account_name=%s&nft_id=%d&money_id=%d&money_amount=%d&is_sell=%v", accountName, AssetId, AssetType, AssetAmount, isSell),
+		"/api/v1/offer/listOffer",
+		url.Values{"accountName": {accountName}},
+		func(ctx context.Context, cfg *Config) (SignableTx, error) {
+			return assembleOfferTx(ctx, cfg, accountName, AssetId, AssetType, AssetAmount, isSell)
+		})
+	if err != nil {
+		return nil, err
+	}
+	env.IsSell = isSell
+	return env, nil
+}
+
+func prepareAcceptOffer(ctx context.Context, cfg *Config, accountName string, offerId int64, isSell bool, AssetAmount *big.Int) (*Envelope, error) {
+	env, err := prepareEnvelope(ctx, cfg, OpAcceptOffer, accountName,
+		fmt.Sprintf("/api/v1/preparetx/getPrepareAtomicMatchWithTx?account_name=%s&offer_id=%d&money_id=%d&money_amount=%s&is_sell=%v", accountName, offerId, 0, AssetAmount.String(), isSell),
+		"/api/v1/offer/acceptOffer",
+		url.Values{"id": {fmt.Sprintf("%d", offerId)}},
+		func(ctx context.Context, cfg *Config) (SignableTx, error) {
+			return assembleAtomicMatchTx(ctx, cfg, accountName, offerId, isSell, AssetAmount)
+		})
+	if err != nil {
+		return nil, err
+	}
+	env.IsSell = isSell
+	env.AssetAmount = AssetAmount.String()
+	return env, nil
+}
+
+func prepareEnvelope(ctx context.Context, cfg *Config, op, accountName, preparePath, submitPath string, form url.Values, assemble assembleFunc) (*Envelope, error) {
+	txType, tx, err := prepareTxInfo(ctx, cfg, preparePath, assemble)
+	if err != nil {
+		return nil, err
+	}
+	var fee *GasFee
+	if feeTx := newFeeTx(op); feeTx != nil {
+		if tx, fee, err = setTxGasFee(ctx, cfg, feeTx, tx); err != nil {
+			return nil, err
+		}
+	}
+	var txInfo struct {
+		ExpiredAt int64
+	}
+	if err := json.Unmarshal([]byte(tx), &txInfo); err != nil {
+		return nil, fmt.Errorf("invalid prepared tx: %v", err)
+	}
+	return &Envelope{
+		Version:     EnvelopeVersion,
+		Operation:   op,
+		AccountName: accountName,
+		TxType:      txType,
+		TxInfo:      tx,
+		ExpiredAt:   txInfo.ExpiredAt,
+		CreatedAt:   time.Now().UnixMilli(),
+		SubmitPath:  submitPath,
+		Form:        form,
+		GasFee:      fee,
+	}, nil
+}
+
+// setTxGasFee sets the gas fee selected by the gas fee policy of ctx in
+// txInfo, decoded into tx to read it. Only the gas fields of txInfo are
+// rewritten, the fields tx does not declare are kept.
+func setTxGasFee(ctx context.Context, cfg *Config, tx feeTx, txInfo string) (string, *GasFee, error) {
+	if err := json.Unmarshal([]byte(txInfo), tx); err != nil {
+		return "", nil, fmt.Errorf("invalid prepared tx: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(txInfo), &fields); err != nil {
+		return "", nil, fmt.Errorf("invalid prepared tx: %v", err)
+	}
+	fee, err := selectGasFee(ctx, cfg, tx.TxType(), paymentAssetId(tx))
+	if err != nil {
+		return "", nil, err
+	}
+	for field, value := range map[string]interface{}{
+		"GasAccountIndex":   fee.AccountIndex,
+		"GasFeeAssetId":     fee.AssetId,
+		"GasFeeAssetAmount": fee.Amount,
+	} {
+		if fields[field], err = json.Marshal(value); err != nil {
+			return "", nil, err
+		}
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return "", nil, err
+	}
+	tx.setGasFee(fee)
+	return string(b), fee, nil
+}
+
+func submitEnvelope(ctx context.Context, cfg *Config, env *Envelope, result interface{}) error {
+	if !env.Signed() {
+		return ErrEnvelopeUnsigned
+	}
+	if env.Expired(time.Now()) {
+		return ErrEnvelopeExpired
+	}
+	form := url.Values{}
+	for k, v := range env.Form {
+		form[k] = v
+	}
+	form.Set("transaction", env.SignedTx)
+	return httpSubmitForm(ctx, cfg, cfg.NftMarketUrl+env.SubmitPath, form, result)
+}
@@ -0,0 +1,136 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeWorkflow(t *testing.T) {
+	expiredAt := time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	var submitted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/preparetx/getPrepareTransferNftTxInfo":
+			if r.URL.Query().Get("to_account_name") != "bob"+NameSuffix {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"tx_type": 11, "transtion": "{\"FromAccountIndex\": 1, \"ToAccountIndex\": 2, \"NftIndex\": 12, \"ExpiredAt\": %d}"}`, expiredAt)
+		case "/api/v1/asset/sendTransferNft":
+			r.ParseForm()
+			if r.Form.Get("asset_id") != "12" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			submitted = r.Form.Get("transaction")
+			w.Write([]byte(`{"success": true}`))
+		case "/api/v1/info/getGasAccount":
+			w.Write([]byte(`{"account_index": 9}`))
+		case "/api/v1/info/getGasFeeAssetList":
+			w.Write([]byte(`{"assets": [{"asset_id": 0}, {"asset_id": 1}]}`))
+		case "/api/v1/info/getGasFee":
+			if r.URL.Query().Get("tx_type") != fmt.Sprint(TxTypeTransferNft) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"gas_fee": "%s00"}`, r.URL.Query().Get("asset_id"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	old := GetConfig()
+	defer SetConfig(old)
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	cfg.LegendUrl = server.URL
+	feeAssetId := int64(1)
+	cfg.GasFee = &GasFeePolicy{AssetId: &feeAssetId}
+	if err := SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "zecrey-offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tx.json")
+
+	env, err := PrepareTransferNftEnvelope("alice", 12, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Operation != OpTransferNft || env.TxType != 11 || env.ExpiredAt != expiredAt ||
+		env.GasFee == nil || env.GasFee.AccountIndex != 9 || env.GasFee.AssetId != 1 || env.GasFee.Amount.Int64() != 100 {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	if err := SubmitEnvelope(env, nil); !errors.Is(err, ErrEnvelopeUnsigned) {
+		t.Fatalf("unsigned envelope submitted: %v", err)
+	}
+	if err := WriteEnvelope(path, env); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err = ReadEnvelope(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both timestamps are in milliseconds
+	if created := time.UnixMilli(env.CreatedAt); time.Since(created) > time.Minute || time.Until(created) > time.Minute {
+		t.Fatalf("created at %d is not in milliseconds", env.CreatedAt)
+	}
+	if err := SignEnvelope(key, env); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteEnvelope(path, env); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err = ReadEnvelope(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &TransferNftTxInfo{}
+	if err := json.Unmarshal([]byte(env.SignedTx), tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTransferNftTx(l2pkOfKey(key), tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.GasAccountIndex != 9 || tx.GasFeeAssetId != 1 || tx.GasFeeAssetAmount.Int64() != 100 || env.GasFee.Amount.Int64() != 100 {
+		t.Fatalf("unexpected signed fee %d of asset %d", tx.GasFeeAssetAmount, tx.GasFeeAssetId)
+	}
+	result := &ResqSendTransferNft{}
+	if err := SubmitEnvelope(env, result); err != nil {
+		t.Fatal(err)
+	}
+	if submitted != env.SignedTx {
+		t.Fatalf("unexpected submitted tx %s", submitted)
+	}
+
+	env.SignedTx = ""
+	env.ExpiredAt = time.Now().Add(-time.Minute).UnixNano() / int64(time.Millisecond)
+	if err := SignEnvelope(key, env); !errors.Is(err, ErrEnvelopeExpired) {
+		t.Fatalf("expired envelope signed: %v", err)
+	}
+	// a hand edited accept offer envelope without offers is refused, not signed
+	accept := &Envelope{Operation: OpAcceptOffer, TxInfo: `{"AccountIndex": 1}`, AssetAmount: "1"}
+	if err := SignEnvelope(key, accept); err == nil || accept.Signed() {
+		t.Fatal("atomic match without offers signed")
+	}
+}
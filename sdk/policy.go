@@ -0,0 +1,308 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/signature"
+)
+
+// ErrPolicyViolation is returned, wrapped, when PolicyKeyManager refuses to sign.
+var ErrPolicyViolation = errors.New("signing policy violation")
+
+// SigningPolicy restricts what a PolicyKeyManager signs. Zero values allow everything.
+type SigningPolicy struct {
+	// AllowedTxTypes lists the TxType* constants that may be signed.
+	AllowedTxTypes []int
+	// MaxOfferAmount caps the AssetAmount of a single buy offer, by asset id.
+	MaxOfferAmount map[int64]*big.Int
+	// DailyOfferLimit caps the sum of the AssetAmount of the buy offers signed
+	// per UTC day, by asset id.
+	DailyOfferLimit map[int64]*big.Int
+	// MaxTransferAmount caps the AssetAmount of a single Transfer or Withdraw,
+	// by asset id.
+	MaxTransferAmount map[int64]*big.Int
+	// DailyTransferLimit caps the sum of the AssetAmount of the Transfers and
+	// Withdraws signed per UTC day, by asset id.
+	DailyTransferLimit map[int64]*big.Int
+	// AllowedTransferAccounts lists the account indexes nfts and assets may be
+	// transferred to.
+	AllowedTransferAccounts []int64
+	// AllowedWithdrawAddresses lists the L1 addresses nfts and assets may be
+	// withdrawn to.
+	AllowedWithdrawAddresses []string
+	// ForbiddenWithdrawAddresses lists the L1 addresses nfts and assets must
+	// not be withdrawn to.
+	ForbiddenWithdrawAddresses []string
+	// MaxGasFee caps the gas fee of a single tx, by fee asset id.
+	MaxGasFee map[int64]*big.Int
+	// AllowedMessages lists the Message actions that may be signed, e.g.
+	// MessageUpdateCollection.
+	AllowedMessages []string
+	// AllowBlindSign lets Sign sign hashes without knowing the tx behind them.
+	AllowBlindSign bool
+}
+
+// PolicyKeyManager decodes every tx before signing it and refuses the ones
+// breaking its policy. It only signs through SignTx, which the Construct*Tx
+// functions use, and SignMessage; plain Sign calls are refused unless
+// AllowBlindSign is set.
+type PolicyKeyManager struct {
+	key    KeyManager
+	policy SigningPolicy
+
+	lock  sync.Mutex
+	day   string
+	spent map[outflowKey]*big.Int
+	now   func() time.Time
+}
+
+// outflowKey identifies a daily limit: the buy offers or the transfers and
+// withdrawals of one asset.
+type outflowKey struct {
+	transfer bool
+	assetId  int64
+}
+
+func NewPolicyKeyManager(key KeyManager, policy SigningPolicy) *PolicyKeyManager {
+	return &PolicyKeyManager{key: key, policy: policy, spent: make(map[outflowKey]*big.Int), now: time.Now}
+}
+
+func (km *PolicyKeyManager) Sign(message []byte, hFunc hash.Hash) ([]byte, error) {
+	if !km.policy.AllowBlindSign {
+		return nil, fmt.Errorf("%w: blind signing is disabled", ErrPolicyViolation)
+	}
+	return km.key.Sign(message, hFunc)
+}
+
+func (km *PolicyKeyManager) SignTx(ctx context.Context, tx SignableTx, message []byte, hFunc hash.Hash) ([]byte, error) {
+	txType, msgHash, err := txMsgHash(tx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPolicyViolation, err)
+	}
+	// the hash must be the one of tx, or checking tx would prove nothing
+	if !bytes.Equal(msgHash, message) {
+		return nil, fmt.Errorf("%w: message is not the hash of the tx", ErrPolicyViolation)
+	}
+	if err := km.check(txType, tx); err != nil {
+		return nil, err
+	}
+	key, amount := outflowOf(tx)
+
+	km.lock.Lock()
+	defer km.lock.Unlock()
+	if day := km.now().UTC().Format("2006-01-02"); day != km.day {
+		km.day, km.spent = day, make(map[outflowKey]*big.Int)
+	}
+	if amount != nil {
+		if err := km.checkDailyLimit(key, amount); err != nil {
+			return nil, err
+		}
+	}
+	var sig []byte
+	if signer, ok := km.key.(ContextKeyManager); ok {
+		sig, err = signer.SignWithContext(ctx, message, hFunc)
+	} else {
+		sig, err = km.key.Sign(message, hFunc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if amount != nil {
+		spent := km.spent[key]
+		if spent == nil {
+			spent = new(big.Int)
+		}
+		km.spent[key] = spent.Add(spent, amount)
+	}
+	return sig, nil
+}
+
+func (km *PolicyKeyManager) SignMessage(ctx context.Context, msg *Message, hFunc hash.Hash) ([]byte, error) {
+	if allowed := km.policy.AllowedMessages; len(allowed) > 0 && !containsString(allowed, msg.Action) {
+		return nil, fmt.Errorf("%w: message %q is not allowed", ErrPolicyViolation, msg.Action)
+	}
+	if signer, ok := km.key.(ContextKeyManager); ok {
+		return signer.SignWithContext(ctx, msg.Bytes(), hFunc)
+	}
+	return km.key.Sign(msg.Bytes(), hFunc)
+}
+
+func (km *PolicyKeyManager) Public() signature.PublicKey {
+	return km.key.Public()
+}
+
+// Close closes the wrapped key manager.
+func (km *PolicyKeyManager) Close() error {
+	if closer, ok := km.key.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (km *PolicyKeyManager) check(txType int, tx SignableTx) error {
+	policy := km.policy
+	if len(policy.AllowedTxTypes) > 0 && !containsInt(policy.AllowedTxTypes, txType) {
+		return fmt.Errorf("%w: tx type %d is not allowed", ErrPolicyViolation, txType)
+	}
+	if tx, ok := tx.(feeTx); ok {
+		if err := checkGasFee(tx.gasFee(), policy.MaxGasFee); err != nil {
+			return fmt.Errorf("%w: %v", ErrPolicyViolation, err)
+		}
+	}
+	switch tx := tx.(type) {
+	case *OfferTxInfo:
+		return km.checkOffer(tx)
+	case *AtomicMatchTxInfo:
+		// only the offer of the signer spends its assets
+		for _, offer := range []*OfferTxInfo{tx.BuyOffer, tx.SellOffer} {
+			if offer != nil && offer.AccountIndex == tx.AccountIndex {
+				if err := km.checkOffer(offer); err != nil {
+					return err
+				}
+			}
+		}
+	case *TransferNftTxInfo:
+		return km.checkTransfer(tx.ToAccountIndex)
+	case *TransferTxInfo:
+		if err := km.checkTransfer(tx.ToAccountIndex); err != nil {
+			return err
+		}
+		return checkAmount("transfer", tx.AssetId, tx.AssetAmount, policy.MaxTransferAmount)
+	case *WithdrawNftTxInfo:
+		return km.checkWithdraw(tx.ToAddress)
+	case *WithdrawTxInfo:
+		if err := km.checkWithdraw(tx.ToAddress); err != nil {
+			return err
+		}
+		return checkAmount("withdraw", tx.AssetId, tx.AssetAmount, policy.MaxTransferAmount)
+	}
+	return nil
+}
+
+func (km *PolicyKeyManager) checkTransfer(toAccountIndex int64) error {
+	allowed := km.policy.AllowedTransferAccounts
+	if len(allowed) > 0 && !containsInt64(allowed, toAccountIndex) {
+		return fmt.Errorf("%w: transfer to account %d is not allowed", ErrPolicyViolation, toAccountIndex)
+	}
+	return nil
+}
+
+func (km *PolicyKeyManager) checkWithdraw(toAddress string) error {
+	if allowed := km.policy.AllowedWithdrawAddresses; len(allowed) > 0 && !containsAddress(allowed, toAddress) {
+		return fmt.Errorf("%w: withdraw to %s is not allowed", ErrPolicyViolation, toAddress)
+	}
+	if containsAddress(km.policy.ForbiddenWithdrawAddresses, toAddress) {
+		return fmt.Errorf("%w: withdraw to %s is forbidden", ErrPolicyViolation, toAddress)
+	}
+	return nil
+}
+
+func (km *PolicyKeyManager) checkOffer(offer *OfferTxInfo) error {
+	if offer.Type != 0 {
+		return nil
+	}
+	return checkAmount("offer", offer.AssetId, offer.AssetAmount, km.policy.MaxOfferAmount)
+}
+
+func checkAmount(kind string, assetId int64, amount *big.Int, maxAmount map[int64]*big.Int) error {
+	if amount == nil {
+		return nil
+	}
+	if amount.Sign() < 0 {
+		return fmt.Errorf("%w: negative %s amount", ErrPolicyViolation, kind)
+	}
+	if max := maxAmount[assetId]; max != nil && amount.Cmp(max) > 0 {
+		return fmt.Errorf("%w: %s amount %s of asset %d exceeds %s", ErrPolicyViolation, kind, amount, assetId, max)
+	}
+	return nil
+}
+
+// checkDailyLimit must be called with km.lock held.
+func (km *PolicyKeyManager) checkDailyLimit(key outflowKey, amount *big.Int) error {
+	limits, kind := km.policy.DailyOfferLimit, "offer"
+	if key.transfer {
+		limits, kind = km.policy.DailyTransferLimit, "transfer"
+	}
+	limit := limits[key.assetId]
+	if limit == nil {
+		return nil
+	}
+	total := new(big.Int).Set(amount)
+	if spent := km.spent[key]; spent != nil {
+		total.Add(total, spent)
+	}
+	if total.Cmp(limit) > 0 {
+		return fmt.Errorf("%w: daily %s limit %s of asset %d exceeded", ErrPolicyViolation, kind, limit, key.assetId)
+	}
+	return nil
+}
+
+// outflowOf returns the amount tx spends toward a daily limit: buy offers
+// count toward DailyOfferLimit, transfers and withdrawals toward
+// DailyTransferLimit. Atomic matches count through the offers signed before
+// them.
+func outflowOf(tx SignableTx) (outflowKey, *big.Int) {
+	switch tx := tx.(type) {
+	case *OfferTxInfo:
+		if tx.Type == 0 {
+			return outflowKey{assetId: tx.AssetId}, tx.AssetAmount
+		}
+	case *TransferTxInfo:
+		return outflowKey{transfer: true, assetId: tx.AssetId}, tx.AssetAmount
+	case *WithdrawTxInfo:
+		return outflowKey{transfer: true, assetId: tx.AssetId}, tx.AssetAmount
+	}
+	return outflowKey{}, nil
+}
+
+// txMsgHash returns the type and the message hash of tx.
+func txMsgHash(tx SignableTx) (int, []byte, error) {
+	msgHash, err := tx.MsgHash(mimc.NewMiMC())
+	return tx.TxType(), msgHash, err
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAddress(addresses []string, address string) bool {
+	for _, value := range addresses {
+		if strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(address)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt64(values []int64, v int64) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
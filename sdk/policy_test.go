@@ -0,0 +1,178 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+)
+
+func TestPolicyKeyManager(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("cd", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	km := NewPolicyKeyManager(key, SigningPolicy{
+		AllowedTxTypes:             []int{TxTypeOffer, TxTypeTransferNft, TxTypeWithdrawNft},
+		MaxOfferAmount:             map[int64]*big.Int{0: big.NewInt(100)},
+		DailyOfferLimit:            map[int64]*big.Int{0: big.NewInt(150)},
+		AllowedTransferAccounts:    []int64{7},
+		ForbiddenWithdrawAddresses: []string{"0x000000000000000000000000000000000000dEaD"},
+	})
+	now := time.Date(2022, 9, 1, 23, 0, 0, 0, time.UTC)
+	km.now = func() time.Time { return now }
+
+	offer := func(amount int64) *OfferTxInfo {
+		return &OfferTxInfo{Type: 0, AccountIndex: 1, AssetId: 0, AssetAmount: big.NewInt(amount)}
+	}
+	refused := func(name string, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrPolicyViolation) {
+			t.Fatalf("%s: expected policy violation, got %v", name, err)
+		}
+	}
+
+	if _, err := ConstructOfferTx(km, offer(100)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ConstructOfferTx(km, offer(101))
+	refused("per tx cap", err)
+	_, err = ConstructOfferTx(km, offer(60))
+	refused("daily cap", err)
+	if _, err := ConstructOfferTx(km, &OfferTxInfo{Type: 1, AssetAmount: big.NewInt(1000)}); err != nil {
+		t.Fatalf("sell offers do not spend: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := ConstructOfferTx(km, offer(60)); err != nil {
+		t.Fatalf("daily cap not reset: %v", err)
+	}
+
+	if _, err := ConstructTransferNftTx(km, &TransferNftTxInfo{FromAccountIndex: 1, ToAccountIndex: 7, GasFeeAssetAmount: big.NewInt(1)}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ConstructTransferNftTx(km, &TransferNftTxInfo{FromAccountIndex: 1, ToAccountIndex: 8, GasFeeAssetAmount: big.NewInt(1)})
+	refused("counterparty", err)
+	_, err = ConstructWithdrawNftTx(km, &WithdrawNftTxInfo{ToAddress: "0x000000000000000000000000000000000000dead", GasFeeAssetAmount: big.NewInt(1)})
+	refused("withdraw destination", err)
+	_, err = ConstructMintNftTx(km, &MintNftTxInfo{GasFeeAssetAmount: big.NewInt(1)})
+	refused("tx type", err)
+
+	_, err = km.Sign([]byte("hash"), mimc.NewMiMC())
+	refused("blind sign", err)
+	_, err = km.SignTx(context.Background(), offer(1), []byte("another hash"), mimc.NewMiMC())
+	refused("hash mismatch", err)
+}
+
+func TestPolicyTransferAndWithdrawLimits(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("cd", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const treasury = "0x00000000000000000000000000000000000000aA"
+	km := NewPolicyKeyManager(key, SigningPolicy{
+		MaxTransferAmount:        map[int64]*big.Int{0: big.NewInt(100)},
+		DailyTransferLimit:       map[int64]*big.Int{0: big.NewInt(150)},
+		AllowedWithdrawAddresses: []string{treasury},
+	})
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	km.now = func() time.Time { return now }
+	fee := big.NewInt(1)
+	transfer := func(amount int64) *TransferTxInfo {
+		return &TransferTxInfo{FromAccountIndex: 1, ToAccountIndex: 2, AssetAmount: big.NewInt(amount), GasFeeAssetAmount: fee}
+	}
+	withdraw := func(amount int64, to string) *WithdrawTxInfo {
+		return &WithdrawTxInfo{FromAccountIndex: 1, AssetAmount: big.NewInt(amount), ToAddress: to, GasFeeAssetAmount: fee}
+	}
+	refused := func(name string, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrPolicyViolation) {
+			t.Fatalf("%s: expected policy violation, got %v", name, err)
+		}
+	}
+
+	_, err = ConstructTransferTx(km, transfer(101))
+	refused("transfer above the cap", err)
+	_, err = ConstructWithdrawTx(km, withdraw(101, treasury))
+	refused("withdraw above the cap", err)
+	_, err = ConstructTransferTx(km, transfer(-1))
+	refused("negative transfer", err)
+	_, err = ConstructWithdrawTx(km, withdraw(1, "0x00000000000000000000000000000000000000bB"))
+	refused("withdraw outside the allowlist", err)
+
+	// transfers and withdrawals share the daily limit
+	if _, err := ConstructTransferTx(km, transfer(100)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ConstructWithdrawTx(km, withdraw(60, treasury))
+	refused("daily limit", err)
+	if _, err := ConstructWithdrawTx(km, withdraw(50, strings.ToLower(treasury))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConstructTransferTx(km, &TransferTxInfo{ToAccountIndex: 2, AssetId: 1, AssetAmount: big.NewInt(1000), GasFeeAssetAmount: fee}); err != nil {
+		t.Fatalf("asset without limits refused: %v", err)
+	}
+	now = now.Add(24 * time.Hour)
+	if _, err := ConstructTransferTx(km, transfer(100)); err != nil {
+		t.Fatalf("daily limit not reset: %v", err)
+	}
+}
+
+func TestPolicyMessagesAndAcceptedAmount(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("cd", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.FormValue("signature")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	km := NewPolicyKeyManager(key, SigningPolicy{
+		AllowedMessages: []string{MessageUpdateCollection},
+		DailyOfferLimit: map[int64]*big.Int{0: big.NewInt(50)},
+	})
+	c := newClientWithKeyManager(cfg, nil, "alice", km, l2pkOfKey(key))
+	if _, err := c.UpdateCollection("1", "name"); err != nil {
+		t.Fatalf("update collection refused under a policy: %v", err)
+	}
+	if signature == "" {
+		t.Fatal("update collection not signed")
+	}
+	km.policy.AllowedMessages = []string{"another"}
+	if _, err := c.UpdateCollection("1", "name"); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("message outside the policy signed: %v", err)
+	}
+
+	// the daily limit counts the accepted amount, not the prepared one
+	prepared, _ := json.Marshal(&AtomicMatchTxInfo{
+		AccountIndex:      1,
+		GasFeeAssetAmount: big.NewInt(1),
+		BuyOffer:          &OfferTxInfo{Type: 0, AccountIndex: 1, AssetAmount: big.NewInt(1)},
+		SellOffer:         &OfferTxInfo{Type: 1, AccountIndex: 2, AssetAmount: big.NewInt(1)},
+	})
+	if _, err := PrepareAtomicMatchWithTx(km, string(prepared), false, big.NewInt(60)); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("accepted amount above the daily limit signed: %v", err)
+	}
+	signed, err := PrepareAtomicMatchWithTx(key, string(prepared), false, big.NewInt(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &AtomicMatchTxInfo{}
+	if err := json.Unmarshal([]byte(signed), tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyOfferTx(l2pkOfKey(key), tx.BuyOffer); err != nil || tx.BuyOffer.AssetAmount.Int64() != 60 {
+		t.Fatalf("accepted amount not signed: %s %v", tx.BuyOffer.AssetAmount, err)
+	}
+}
@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointFamily groups the endpoints that share a rate limit.
+type EndpointFamily string
+
+const (
+	FamilyMarketplace EndpointFamily = "marketplace" // NftMarketUrl REST
+	FamilyLegend      EndpointFamily = "legend"      // LegendUrl REST
+	FamilyHasura      EndpointFamily = "hasura"      // HasuraUrl GraphQL
+	FamilyL1RPC       EndpointFamily = "l1_rpc"      // ChainRpcUrl JSON-RPC
+)
+
+// RateLimiter is a token bucket holding up to burst tokens, refilled at rate
+// tokens per second. It is safe for concurrent use, share one limiter between
+// all the goroutines and clients that should be limited together.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a full bucket allowing rate requests per second with
+// bursts of up to burst requests.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay, err := l.reserve(ctx)
+		if err != nil || delay == 0 {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Allow takes a token if one is available without waiting.
+func (l *RateLimiter) Allow() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return true
+	}
+	return false
+}
+
+// reserve takes a token, or returns how long to wait for the next one.
+func (l *RateLimiter) reserve(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := time.Now()
+	l.refill(now)
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, nil
+	}
+	if l.rate <= 0 {
+		return 0, fmt.Errorf("rate limiter: zero rate")
+	}
+	delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		return 0, context.DeadlineExceeded
+	}
+	return delay, nil
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	l.tokens += elapsed * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// familyOf returns the endpoint family of a request url, "" for unknown hosts.
+func (cfg *Config) familyOf(url string) EndpointFamily {
+	if cfg.HasuraUrl != "" && strings.HasPrefix(url, cfg.HasuraUrl) {
+		return FamilyHasura
+	}
+	for _, family := range []EndpointFamily{FamilyMarketplace, FamilyLegend, FamilyL1RPC} {
+		for _, base := range cfg.endpointsOf(family) {
+			if strings.HasPrefix(url, base) {
+				return family
+			}
+		}
+	}
+	return ""
+}
+
+// wait blocks on the rate limiter of family, if any.
+func (cfg *Config) wait(ctx context.Context, family EndpointFamily) error {
+	limiter := cfg.RateLimiters[family]
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
+
+// rateLimitedTransport waits on limiter before every round trip, it limits
+// the L1 RPC calls made through go-ethereum clients.
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,63 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(50, 2)
+	if !limiter.Allow() || !limiter.Allow() || limiter.Allow() {
+		t.Fatal("burst not honored")
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("5 tokens at 50/s took only %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := NewRateLimiter(0.1, 1).Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.Wait(ctx); err == nil {
+		t.Fatal("expected the deadline to be exceeded")
+	}
+}
+
+func TestRateLimitedFamily(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"nonce": 1}`))
+	}))
+	defer server.Close()
+
+	legend := NewRateLimiter(1, 1)
+	cfg := DefaultConfig()
+	cfg.LegendUrl = server.URL
+	cfg.RateLimiters = map[EndpointFamily]*RateLimiter{FamilyLegend: legend}
+	if cfg.familyOf(server.URL+"/api/v1/tx/getNextNonce") != FamilyLegend {
+		t.Fatal("unexpected endpoint family")
+	}
+	if err := httpGet(context.Background(), cfg, server.URL+"/api/v1/tx/getNextNonce", &RespGetNextNonce{}); err != nil {
+		t.Fatal(err)
+	}
+	if legend.Allow() {
+		t.Fatal("request did not take a token")
+	}
+}
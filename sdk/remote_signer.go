@@ -0,0 +1,275 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	"github.com/consensys/gnark-crypto/signature"
+)
+
+const (
+	signerPublicPath = "/v1/public"
+	signerSignPath   = "/v1/sign"
+
+	defaultSignerTimeout = 10 * time.Second
+)
+
+// ReqRemoteSign is the body of POST /v1/sign. Message is the hex encoded
+// tx hash, which the signer signs with MiMC like SeedKeyManager.
+type ReqRemoteSign struct {
+	KeyId   string `json:"key_id"`
+	Message string `json:"message"`
+}
+
+type RespRemoteSign struct {
+	Signature string `json:"signature"`
+}
+
+// RespRemotePublic is the body of GET /v1/public?key_id=...
+type RespRemotePublic struct {
+	KeyId     string `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+type respRemoteError struct {
+	Error string `json:"error"`
+}
+
+// RemoteSignerConfig configures a RemoteKeyManager.
+type RemoteSignerConfig struct {
+	Url   string // e.g. https://signer.internal:8443
+	KeyId string
+	// TLS authenticates the signer and, with a client certificate, the caller.
+	// See LoadMutualTLSConfig.
+	TLS *tls.Config
+	// HTTPClient overrides the client built from TLS.
+	HTTPClient *http.Client
+	Timeout    time.Duration // of every signer request, defaults to 10s
+}
+
+// RemoteKeyManager forwards the messages to sign to a signer service, so
+// that the host using the SDK never holds the key.
+type RemoteKeyManager struct {
+	cfg    RemoteSignerConfig
+	client *http.Client
+	public signature.PublicKey
+}
+
+// NewRemoteKeyManager connects to the signer and fetches the public key of cfg.KeyId.
+func NewRemoteKeyManager(ctx context.Context, cfg RemoteSignerConfig) (*RemoteKeyManager, error) {
+	if cfg.Url == "" {
+		return nil, errors.New("remote signer: url is required")
+	}
+	cfg.Url = strings.TrimRight(cfg.Url, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSignerTimeout
+	}
+	km := &RemoteKeyManager{cfg: cfg, client: cfg.HTTPClient}
+	if km.client == nil {
+		km.client = &http.Client{Transport: &http.Transport{TLSClientConfig: cfg.TLS}}
+	}
+	resp := &RespRemotePublic{}
+	if err := km.call(ctx, http.MethodGet, signerPublicPath+"?"+url.Values{"key_id": {cfg.KeyId}}.Encode(), nil, resp); err != nil {
+		return nil, err
+	}
+	pkBytes, err := hex.DecodeString(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer: invalid public key: %v", err)
+	}
+	pk := &eddsa.PublicKey{}
+	if _, err := pk.SetBytes(pkBytes); err != nil {
+		return nil, fmt.Errorf("remote signer: invalid public key: %v", err)
+	}
+	km.public = pk
+	return km, nil
+}
+
+func (km *RemoteKeyManager) Sign(message []byte, hFunc hash.Hash) ([]byte, error) {
+	return km.SignWithContext(context.Background(), message, hFunc)
+}
+
+// SignWithContext asks the signer to sign message. The signer always hashes
+// with MiMC, hFunc is ignored.
+func (km *RemoteKeyManager) SignWithContext(ctx context.Context, message []byte, hFunc hash.Hash) ([]byte, error) {
+	body, err := json.Marshal(&ReqRemoteSign{KeyId: km.cfg.KeyId, Message: hex.EncodeToString(message)})
+	if err != nil {
+		return nil, err
+	}
+	resp := &RespRemoteSign{}
+	if err := km.call(ctx, http.MethodPost, signerSignPath, body, resp); err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer: invalid signature: %v", err)
+	}
+	// never trust the signer blindly, a wrong key would only fail on chain
+	if ok, err := km.public.Verify(sig, message, mimc.NewMiMC()); err != nil || !ok {
+		return nil, errors.New("remote signer: signature does not match the public key")
+	}
+	return sig, nil
+}
+
+func (km *RemoteKeyManager) Public() signature.PublicKey {
+	return km.public
+}
+
+// Close closes the idle connections to the signer.
+func (km *RemoteKeyManager) Close() error {
+	km.client.CloseIdleConnections()
+	return nil
+}
+
+func (km *RemoteKeyManager) call(ctx context.Context, method, path string, body []byte, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, km.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, km.cfg.Url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := km.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote signer: %w", err)
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("remote signer: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := &respRemoteError{}
+		if json.Unmarshal(content, e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(content))
+		}
+		return fmt.Errorf("remote signer: status %d: %s", resp.StatusCode, e.Error)
+	}
+	return json.Unmarshal(content, result)
+}
+
+// NewSignerHandler serves the remote signer API for the given keys, by key id.
+// Every request is logged with the common name of the client certificate;
+// messages and signatures are never logged. It is meant to be served over
+// mutual TLS, see LoadMutualTLSConfig.
+func NewSignerHandler(keys map[string]KeyManager, logger Logger) http.Handler {
+	if logger == nil {
+		logger = NopLogger()
+	}
+	h := &signerHandler{keys: keys, logger: redactingLogger{logger: logger}}
+	mux := http.NewServeMux()
+	mux.HandleFunc(signerPublicPath, h.public)
+	mux.HandleFunc(signerSignPath, h.sign)
+	return mux
+}
+
+type signerHandler struct {
+	keys   map[string]KeyManager
+	logger Logger
+}
+
+func (h *signerHandler) public(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.fail(w, r, http.StatusMethodNotAllowed, "", errors.New("method not allowed"))
+		return
+	}
+	keyId := r.URL.Query().Get("key_id")
+	key, ok := h.keys[keyId]
+	if !ok {
+		h.fail(w, r, http.StatusNotFound, keyId, errors.New("unknown key"))
+		return
+	}
+	h.logger.Info("public key", "client", clientName(r), "key_id", keyId)
+	writeJSON(w, http.StatusOK, &RespRemotePublic{KeyId: keyId, PublicKey: hex.EncodeToString(key.Public().Bytes())})
+}
+
+func (h *signerHandler) sign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.fail(w, r, http.StatusMethodNotAllowed, "", errors.New("method not allowed"))
+		return
+	}
+	req := &ReqRemoteSign{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(req); err != nil {
+		h.fail(w, r, http.StatusBadRequest, "", err)
+		return
+	}
+	key, ok := h.keys[req.KeyId]
+	if !ok {
+		h.fail(w, r, http.StatusNotFound, req.KeyId, errors.New("unknown key"))
+		return
+	}
+	message, err := hex.DecodeString(req.Message)
+	if err != nil {
+		h.fail(w, r, http.StatusBadRequest, req.KeyId, errors.New("invalid message"))
+		return
+	}
+	sig, err := withContext(r.Context(), key).Sign(message, mimc.NewMiMC())
+	if err != nil {
+		h.fail(w, r, http.StatusInternalServerError, req.KeyId, err)
+		return
+	}
+	h.logger.Info("signed", "client", clientName(r), "key_id", req.KeyId, "message_size", len(message))
+	writeJSON(w, http.StatusOK, &RespRemoteSign{Signature: hex.EncodeToString(sig)})
+}
+
+func (h *signerHandler) fail(w http.ResponseWriter, r *http.Request, status int, keyId string, err error) {
+	h.logger.Warn("request rejected", "client", clientName(r), "path", r.URL.Path, "key_id", keyId, "status", status, "err", err)
+	writeJSON(w, status, &respRemoteError{Error: err.Error()})
+}
+
+func clientName(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return r.RemoteAddr
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// LoadMutualTLSConfig builds the TLS config of either side of a mutually
+// authenticated connection: certFile/keyFile is the own certificate and caFile
+// the CA the peer certificate must be signed by.
+func LoadMutualTLSConfig(certFile, keyFile, caFile string, server bool) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate found in %s", caFile)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if server {
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
@@ -0,0 +1,104 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	ca, caKey := testCert(t, "ca", nil, nil)
+	serverCert := testTLSCert(t, "127.0.0.1", ca, caKey)
+	clientCert := testTLSCert(t, "sdk-client", ca, caKey)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := &recordLogger{}
+	srv := httptest.NewUnstartedServer(NewSignerHandler(map[string]KeyManager{"alice": key, "ops&team+1": key}, logger))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	clientTLS := &tls.Config{Certificates: []tls.Certificate{clientCert}, RootCAs: pool}
+	remote, err := NewRemoteKeyManager(context.Background(), RemoteSignerConfig{Url: srv.URL, KeyId: "alice", TLS: clientTLS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(remote.Public().Bytes()) != string(key.Public().Bytes()) {
+		t.Fatal("remote public key differs")
+	}
+	msg := []byte("message")
+	sig, err := remote.Sign(msg, mimc.NewMiMC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := key.Public().Verify(sig, msg, mimc.NewMiMC()); err != nil || !ok {
+		t.Fatal("remote signature does not verify")
+	}
+	if !strings.Contains(strings.Join(logger.entries, "\n"), "client=sdk-client") {
+		t.Fatalf("request not logged with the client name: %s", strings.Join(logger.entries, "\n"))
+	}
+
+	if _, err := NewRemoteKeyManager(context.Background(), RemoteSignerConfig{Url: srv.URL, KeyId: "bob", TLS: clientTLS}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+	if _, err := NewRemoteKeyManager(context.Background(), RemoteSignerConfig{Url: srv.URL, KeyId: "ops&team+1", TLS: clientTLS}); err != nil {
+		t.Fatalf("key id not escaped: %v", err)
+	}
+	noCert := &tls.Config{RootCAs: pool}
+	if _, err := NewRemoteKeyManager(context.Background(), RemoteSignerConfig{Url: srv.URL, KeyId: "alice", TLS: noCert}); err == nil {
+		t.Fatal("signer accepted a client without certificate")
+	}
+}
+
+func testCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	} else if ip := net.ParseIP(name); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func testTLSCert(t *testing.T, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) tls.Certificate {
+	cert, key := testCert(t, name, ca, caKey)
+	return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+}
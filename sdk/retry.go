@@ -0,0 +1,166 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// ErrTxAccepted is returned when a submission failed in transit but the
+// nonce lookup shows the signed tx was accepted anyway. The tx must not be
+// submitted again; its result has to be looked up instead.
+var ErrTxAccepted = errors.New("tx already accepted")
+
+// RetryPolicy controls how failed requests are retried.
+// Reads and prepare-tx calls are always safe to retry. Tx submissions are only
+// retried when the SDK can prove the signed tx was not accepted.
+type RetryPolicy struct {
+	MaxAttempts    int // including the first one, <= 1 disables retries
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64 // fraction of the backoff that is randomized, 0 to 1
+
+	// RetryableStatus reports whether a response status is transient.
+	// Defaults to 408, 429, 500, 502, 503 and 504.
+	RetryableStatus func(status int) bool
+}
+
+// NoRetry disables retries when set as Config.Retry.
+var NoRetry = &RetryPolicy{MaxAttempts: 1}
+
+// DefaultRetryPolicy is used by configs without their own Retry policy.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+var defaultRetryPolicy = DefaultRetryPolicy()
+
+// acceptedFunc reports whether a submitted tx has been accepted by the backend.
+type acceptedFunc func(ctx context.Context) (bool, error)
+
+// backoff returns the delay before the given retry, counting from 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if p.RetryableStatus != nil {
+			return p.RetryableStatus(apiErr.StatusCode)
+		}
+		return apiErr.Retryable
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// connection refused or reset, and connections closed mid response
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetry runs do until it succeeds, fails permanently or the attempts are
+// exhausted. A nil accepted means do is idempotent. Otherwise do is retried only
+// after accepted proves the previous attempt did not land.
+func withRetry(ctx context.Context, cfg *Config, accepted acceptedFunc, do func() error) error {
+	policy := cfg.retryPolicy()
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = do()
+		if err == nil || attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return err
+		}
+		delay := policy.backoff(attempt)
+		cfg.logger().Warn("retrying request", "attempt", attempt, "delay", delay, "err", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if accepted != nil {
+			ok, checkErr := accepted(ctx)
+			if checkErr != nil {
+				// can not prove the tx was not accepted, do not submit it twice
+				return err
+			}
+			if ok {
+				cfg.logger().Warn("not resubmitting accepted tx", "err", err)
+				return fmt.Errorf("%w: %v", ErrTxAccepted, err)
+			}
+		}
+	}
+}
+
+// acceptedByNonce proves acceptance of a signed tx through the next nonce of
+// its sender: once the backend has accepted the tx, the next nonce is past it.
+// It returns nil, meaning the submission is not retried, for txs without a nonce.
+func acceptedByNonce(cfg *Config, tx string) acceptedFunc {
+	var info struct {
+		AccountIndex        *int64
+		FromAccountIndex    *int64
+		CreatorAccountIndex *int64
+		Nonce               *int64
+	}
+	if err := json.Unmarshal([]byte(tx), &info); err != nil || info.Nonce == nil {
+		return nil
+	}
+	var accountIndex int64
+	switch {
+	case info.FromAccountIndex != nil:
+		accountIndex = *info.FromAccountIndex
+	case info.CreatorAccountIndex != nil && info.AccountIndex == nil:
+		accountIndex = *info.CreatorAccountIndex
+	case info.AccountIndex != nil:
+		accountIndex = *info.AccountIndex
+	default:
+		return nil
+	}
+	nonce := *info.Nonce
+	return func(ctx context.Context) (bool, error) {
+		result := &RespGetNextNonce{}
+		if err := httpGet(ctx, cfg, cfg.LegendUrl+fmt.Sprintf("/api/v1/tx/getNextNonce?account_index=%d", accountIndex), result); err != nil {
+			return false, err
+		}
+		return result.Nonce > nonce, nil
+	}
+}
+
+func (cfg *Config) retryPolicy() *RetryPolicy {
+	if cfg.Retry != nil {
+		return cfg.Retry
+	}
+	return defaultRetryPolicy
+}
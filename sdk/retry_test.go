@@ -0,0 +1,86 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var (
+		prepareCalls int
+		hasuraCalls  int
+		submitCalls  int
+		nextNonce    int64
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/preparetx/getPrepareMintNftTxInfo":
+			prepareCalls++
+			if prepareCalls < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"transtion": "{}"}`))
+		case "/v1/graphql":
+			hasuraCalls++
+			if hasuraCalls < 2 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"data": {}}`))
+		case "/api/v1/tx/getNextNonce":
+			fmt.Fprintf(w, `{"nonce": %d}`, nextNonce)
+		default:
+			submitCalls++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.LegendUrl = server.URL
+	cfg.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2, Jitter: 0.5}
+	ctx := context.Background()
+
+	if err := httpGet(ctx, cfg, server.URL+"/api/v1/preparetx/getPrepareMintNftTxInfo", &RespetPreparetxInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if prepareCalls != 3 {
+		t.Fatalf("expected 3 prepare calls, got %d", prepareCalls)
+	}
+
+	cfg.HasuraUrl = server.URL + "/v1/graphql"
+	if _, err := post2Hasura(ctx, cfg, []byte(`{"query": "{}"}`)); err != nil || hasuraCalls != 2 {
+		t.Fatalf("hasura query not retried, %d calls: %v", hasuraCalls, err)
+	}
+	hasuraCalls = 0
+	if _, err := post2Hasura(ctx, cfg, []byte(`{"query": "mutation { delete_offer(where: {}) { affected_rows } }"}`)); err == nil || hasuraCalls != 1 {
+		t.Fatalf("hasura mutation retried, %d calls: %v", hasuraCalls, err)
+	}
+
+	tx := url.Values{"transaction": {`{"CreatorAccountIndex": 2, "Nonce": 5}`}}
+	nextNonce = 5
+	err := httpSubmitForm(ctx, cfg, server.URL+"/api/v1/asset/createAsset", tx, &RespCreateAsset{})
+	if submitCalls != 3 || errors.Is(err, ErrTxAccepted) {
+		t.Fatalf("expected 3 submissions of a not accepted tx, got %d: %v", submitCalls, err)
+	}
+
+	submitCalls = 0
+	nextNonce = 6
+	err = httpSubmitForm(ctx, cfg, server.URL+"/api/v1/asset/createAsset", tx, &RespCreateAsset{})
+	if submitCalls != 1 || !errors.Is(err, ErrTxAccepted) {
+		t.Fatalf("accepted tx must not be resubmitted, got %d: %v", submitCalls, err)
+	}
+
+	submitCalls = 0
+	err = httpSubmitForm(ctx, cfg, server.URL+"/api/v1/offer/listOffer", url.Values{"transaction": {`{"AccountIndex": 2}`}}, &RespListOffer{})
+	if submitCalls != 1 || err == nil {
+		t.Fatalf("tx without nonce must not be retried, got %d: %v", submitCalls, err)
+	}
+}
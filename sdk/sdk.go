@@ -5,155 +5,161 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"math/big"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/zecrey-labs/zecrey-crypto/util/ecdsaHelper"
 	"github.com/zecrey-labs/zecrey-crypto/util/eddsaHelper"
 	"github.com/zecrey-labs/zecrey-eth-rpc/_rpc"
 	zecreyLegendRpc "github.com/zecrey-labs/zecrey-eth-rpc/zecrey/core/zecrey-legend"
 	zecreyLegendUtil "github.com/zecrey-labs/zecrey-legend/common/util"
-	"github.com/zeromicro/go-zero/core/logx"
 )
 
 func GetAccountL1Address(accountName string) (common.Address, error) {
-	providerClient, err := _rpc.NewClient(chainRpcUrl)
+	return GetAccountL1AddressWithContext(context.Background(), accountName)
+}
+
+func GetAccountL1AddressWithContext(ctx context.Context, accountName string) (common.Address, error) {
+	cfg := GetConfig()
+	zecreyInstance, err := loadZecreyLegend(ctx, cfg)
 	if err != nil {
-		return BytesToAddress([]byte{}), fmt.Errorf(fmt.Sprintf("wrong rpc url:%s", chainRpcUrl))
+		return BytesToAddress([]byte{}), err
 	}
-	res, err := zecreyLegendUtil.ComputeAccountNameHashInBytes(accountName + NameSuffix)
+	return getAccountL1Address(ctx, cfg, zecreyInstance, accountName)
+}
+
+// loadZecreyLegend binds the zecrey legend contract of cfg.
+func loadZecreyLegend(ctx context.Context, cfg *Config) (*zecreyLegendRpc.ZecreyLegend, error) {
+	providerClient, err := dialChain(ctx, cfg)
 	if err != nil {
-		logx.Error(err)
-		return BytesToAddress([]byte{}), err
+		return nil, err
 	}
 	//get base contract address
-	resp, err := GetLayer2BasicInfo()
+	resp, err := getLayer2BasicInfo(ctx, cfg)
 	if err != nil {
-		return BytesToAddress([]byte{}), err
+		return nil, err
 	}
 	ZecreyLegendContract := resp.ContractAddresses[0]
 	//ZnsPriceOracle := resp.ContractAddresses[1]
+	return zecreyLegendRpc.LoadZecreyLegendInstance(providerClient, ZecreyLegendContract)
+}
 
-	resBytes := zecreyLegendUtil.SetFixed32Bytes(res)
-	zecreyInstance, err := zecreyLegendRpc.LoadZecreyLegendInstance(providerClient, ZecreyLegendContract)
+func getAccountL1Address(ctx context.Context, cfg *Config, zecreyInstance *zecreyLegendRpc.ZecreyLegend, accountName string) (common.Address, error) {
+	res, err := zecreyLegendUtil.ComputeAccountNameHashInBytes(accountName + NameSuffix)
 	if err != nil {
+		cfg.logger().Error("[GetAccountL1Address] ComputeAccountNameHashInBytes failed", "err", err)
 		return BytesToAddress([]byte{}), err
 	}
+	resBytes := zecreyLegendUtil.SetFixed32Bytes(res)
 	// fetch by accountNameHash
-	addr, err := zecreyInstance.GetAddressByAccountNameHash(zecreyLegendRpc.EmptyCallOpts(), resBytes)
+	addr, err := zecreyInstance.GetAddressByAccountNameHash(&bind.CallOpts{Context: ctx}, resBytes)
 	if err != nil {
-		logx.Error(err)
+		cfg.logger().Error("[GetAccountL1Address] GetAddressByAccountNameHash failed", "err", err)
 		return BytesToAddress([]byte{}), err
 	}
 	if bytes.Equal(addr.Bytes(), BytesToAddress([]byte{}).Bytes()) {
-		return BytesToAddress([]byte{}), fmt.Errorf("null address")
+		return BytesToAddress([]byte{}), fmt.Errorf("%w: null address", ErrAccountNotFound)
 	}
 	return addr, nil
 }
 
 func GetLayer2BasicInfo() (*RespGetLayer2BasicInfo, error) {
-	resp, err := http.Get(legendUrl + "/api/v1/info/getLayer2BasicInfo")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
+	return GetLayer2BasicInfoWithContext(context.Background())
+}
+
+func GetLayer2BasicInfoWithContext(ctx context.Context) (*RespGetLayer2BasicInfo, error) {
+	return getLayer2BasicInfo(ctx, GetConfig())
+}
+
+func getLayer2BasicInfo(ctx context.Context, cfg *Config) (*RespGetLayer2BasicInfo, error) {
 	result := &RespGetLayer2BasicInfo{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+"/api/v1/info/getLayer2BasicInfo", result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
 func GetAccountByAccountName(accountName string) (*RespGetAccountByAccountName, error) {
-	resp, err := http.Get(nftMarketUrl + fmt.Sprintf("/api/v1/account/getAccountByAccountName?account_name=%s", accountName))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
+	return GetAccountByAccountNameWithContext(context.Background(), accountName)
+}
+
+func GetAccountByAccountNameWithContext(ctx context.Context, accountName string) (*RespGetAccountByAccountName, error) {
+	return getAccountByAccountName(ctx, GetConfig(), accountName)
+}
+
+func getAccountByAccountName(ctx context.Context, cfg *Config, accountName string) (*RespGetAccountByAccountName, error) {
 	result := &RespGetAccountByAccountName{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpGet(ctx, cfg, cfg.NftMarketUrl+fmt.Sprintf("/api/v1/account/getAccountByAccountName?account_name=%s", accountName), result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
 func GetNextNonce(accountIdx int64) (int64, error) {
-	resp, err := http.Get(legendUrl +
-		fmt.Sprintf("/api/v1/tx/getNextNonce?account_index=%d", accountIdx))
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf(string(body))
-	}
+	return GetNextNonceWithContext(context.Background(), accountIdx)
+}
+
+func GetNextNonceWithContext(ctx context.Context, accountIdx int64) (int64, error) {
+	return getNextNonce(ctx, GetConfig(), accountIdx)
+}
+
+func getNextNonce(ctx context.Context, cfg *Config, accountIdx int64) (int64, error) {
 	result := &RespGetNextNonce{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+fmt.Sprintf("/api/v1/tx/getNextNonce?account_index=%d", accountIdx), result); err != nil {
 		return 0, err
 	}
 	return result.Nonce, nil
 }
 
 func GetAccountIndex(accountName string) (int64, error) {
-	resp, err := http.Get(nftMarketUrl + fmt.Sprintf("/api/v1/account/getAccountByAccountName?account_name=%s", accountName))
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	return GetAccountIndexWithContext(context.Background(), accountName)
+}
+
+func GetAccountIndexWithContext(ctx context.Context, accountName string) (int64, error) {
+	return getAccountIndex(ctx, GetConfig(), accountName)
+}
+
+func getAccountIndex(ctx context.Context, cfg *Config, accountName string) (int64, error) {
+	result, err := getAccountByAccountName(ctx, cfg, accountName)
 	if err != nil {
 		return 0, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf(string(body))
-	}
-	result := &RespGetAccountByAccountName{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, err
-	}
 	return result.Account.AccountIndex, nil
 }
 
 func IfAccountRegistered(accountName string) (bool, error) {
-	c, err := newZecreyMarketplaceClientDefault(accountName)
+	return IfAccountRegisteredWithContext(context.Background(), accountName)
+}
+
+func IfAccountRegisteredWithContext(ctx context.Context, accountName string) (bool, error) {
+	return ifAccountRegistered(ctx, GetConfig(), accountName)
+}
+
+func ifAccountRegistered(ctx context.Context, cfg *Config, accountName string) (bool, error) {
+	providerClient, err := dialChain(ctx, cfg)
 	if err != nil {
-		logx.Error(err)
+		cfg.logger().Error("[IfAccountRegistered] dial chain failed", "err", err)
 		return false, err
 	}
 	res, err := zecreyLegendUtil.ComputeAccountNameHashInBytes(accountName + NameSuffix)
 	if err != nil {
-		logx.Error(err)
+		cfg.logger().Error("[IfAccountRegistered] ComputeAccountNameHashInBytes failed", "err", err)
 		return false, err
 	}
 	//get base contract address
-	resp, err := GetLayer2BasicInfo()
+	resp, err := getLayer2BasicInfo(ctx, cfg)
 	if err != nil {
 		return false, err
 	}
@@ -161,44 +167,45 @@ func IfAccountRegistered(accountName string) (bool, error) {
 	//ZnsPriceOracle := resp.ContractAddresses[1]
 
 	resBytes := zecreyLegendUtil.SetFixed32Bytes(res)
-	zecreyInstance, err := zecreyLegendRpc.LoadZecreyLegendInstance(c.providerClient, ZecreyLegendContract)
+	zecreyInstance, err := zecreyLegendRpc.LoadZecreyLegendInstance(providerClient, ZecreyLegendContract)
 	if err != nil {
 		return false, err
 	}
 	// fetch by accountNameHash
-	addr, err := zecreyInstance.GetAddressByAccountNameHash(zecreyLegendRpc.EmptyCallOpts(), resBytes)
+	addr, err := zecreyInstance.GetAddressByAccountNameHash(&bind.CallOpts{Context: ctx}, resBytes)
 	if err != nil {
-		logx.Error(err)
+		cfg.logger().Error("[IfAccountRegistered] GetAddressByAccountNameHash failed", "err", err)
 		return false, err
 	}
 	return !bytes.Equal(addr.Bytes(), BytesToAddress([]byte{}).Bytes()), nil
 }
 
 func GetCategories() (*RespGetCollectionCategories, error) {
-	resp, err := http.Get(nftMarketUrl + fmt.Sprintf("/api/v1/collection/getCollectionCategories"))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
+	return GetCategoriesWithContext(context.Background())
+}
+
+func GetCategoriesWithContext(ctx context.Context) (*RespGetCollectionCategories, error) {
+	cfg := GetConfig()
 	result := &RespGetCollectionCategories{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpGet(ctx, cfg, cfg.NftMarketUrl+fmt.Sprintf("/api/v1/collection/getCollectionCategories"), result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
 func GetCollectionById(collectionId int64) (*RespGetCollectionByCollectionId, error) {
-	request_query := fmt.Sprintf("query MyQuery {\n  actionGetCollectionById(collection_id: %d) {\n    collection {\n      account_name\n      banner_thumb\n    }\n  }\n}\n", collectionId)
+	return GetCollectionByIdWithContext(context.Background(), collectionId)
+}
+
+func GetCollectionByIdWithContext(ctx context.Context, collectionId int64) (*RespGetCollectionByCollectionId, error) {
+	return getCollectionById(ctx, GetConfig(), collectionId)
+}
+
+func getCollectionById(ctx context.Context, cfg *Config, collectionId int64) (*RespGetCollectionByCollectionId, error) {
+	request_query := fmt.Sprintf("query MyQuery {\n  actionGetCollectionById(collection_id: %d) {\n    collection {\n      account_name\n      banner_thumb\n      creator_earning_rate\n      l2_collection_id\n    }\n  }\n}\n", collectionId)
 	input := InputCollectionByIdActionBody{CollectionId: collectionId}
 	action := ActionBody{Name: "actionGetCollectionById"}
-	SessionVariables := SessionVariablesBody{XHasuraUserId: "x-hasura-role", XHasuraRole: "admin"}
+	SessionVariables := cfg.sessionVariables()
 	req := ReqGetCollectionById{
 		Input:            input,
 		Action:           action,
@@ -206,30 +213,23 @@ func GetCollectionById(collectionId int64) (*RespGetCollectionByCollectionId, er
 		RequestQuery:     request_query,
 	}
 	statusJSON, _ := json.Marshal(req)
-	resp, err := http.Post(nftMarketUrl+"/api/v1/action/actionGetCollectionById", "application/json", bytes.NewReader(statusJSON))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespGetCollectionByCollectionId{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpPostJSON(ctx, cfg, cfg.NftMarketUrl+"/api/v1/action/actionGetCollectionById", statusJSON, result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
 func GetCollectionsByAccountIndex(AccountIndex int64) (*RespGetAccountCollections, error) {
+	return GetCollectionsByAccountIndexWithContext(context.Background(), AccountIndex)
+}
+
+func GetCollectionsByAccountIndexWithContext(ctx context.Context, AccountIndex int64) (*RespGetAccountCollections, error) {
+	cfg := GetConfig()
 	request_query := fmt.Sprintf("query MyQuery {\n  actionGetAccountCollections(account_index: %d) {\n    confirmedCollectionIdList\n    pendingCollections {\n      account_name\n      banner_image\n      banner_thumb\n      browse_count\n      category_id\n      created_at\n      creator_earning_rate\n      description\n      discord_link\n      expired_at\n      external_link\n      featured_Thumb\n      featured_image\n      floor_price\n      id\n      instagram_link\n      item_count\n      l2_collection_id\n      logo_image\n      logo_thumb\n      name\n      one_day_trade_volume\n      short_name\n      status\n      telegram_link\n      total_trade_volume\n      twitter_link\n    }\n  }\n}", AccountIndex)
 	input := InputGetAccountCollectionsActionBody{AccountIndex: AccountIndex}
 	action := ActionBody{Name: "actionGetAccountCollections"}
-	SessionVariables := SessionVariablesBody{XHasuraUserId: "x-hasura-role", XHasuraRole: "admin"}
+	SessionVariables := cfg.sessionVariables()
 	req := ReqGetAccountCollections{
 		Input:            input,
 		Action:           action,
@@ -237,20 +237,8 @@ func GetCollectionsByAccountIndex(AccountIndex int64) (*RespGetAccountCollection
 		RequestQuery:     request_query,
 	}
 	statusJSON, _ := json.Marshal(req)
-	resp, err := http.Post(nftMarketUrl+"/api/v1/action/actionGetAccountCollections", "application/json", bytes.NewReader(statusJSON))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespGetAccountCollections{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpPostJSON(ctx, cfg, cfg.NftMarketUrl+"/api/v1/action/actionGetAccountCollections", statusJSON, result); err != nil {
 		return nil, err
 	}
 	//get collections status = 1
@@ -258,10 +246,15 @@ func GetCollectionsByAccountIndex(AccountIndex int64) (*RespGetAccountCollection
 }
 
 func GetAccountNFTs(AccountIndex int64) (*RespGetAccountAssets, error) {
+	return GetAccountNFTsWithContext(context.Background(), AccountIndex)
+}
+
+func GetAccountNFTsWithContext(ctx context.Context, AccountIndex int64) (*RespGetAccountAssets, error) {
+	cfg := GetConfig()
 	request_query := fmt.Sprintf("query MyQuery {\n  actionGetAccountAssets(account_index: %d) {\n    confirmedAssetIdList\n    pendingAssets {\n      account_name\n      audio_thumb\n      collection_id\n      content_hash\n      creator_earning_rate\n      created_at\n      description\n      expired_at\n      id\n      image_thumb\n      levels\n      media\n      name\n      nft_index\n      properties\n      stats\n      status\n      video_thumb\n    }\n  }\n}", AccountIndex)
 	input := InputAssetActionBody{AccountIndex: AccountIndex}
 	action := ActionBody{Name: "actionGetAccountAssets"}
-	SessionVariables := SessionVariablesBody{XHasuraUserId: "x-hasura-role", XHasuraRole: "admin"}
+	SessionVariables := cfg.sessionVariables()
 	req := ReqGetAccountAssets{
 		Input:            input,
 		Action:           action,
@@ -269,20 +262,8 @@ func GetAccountNFTs(AccountIndex int64) (*RespGetAccountAssets, error) {
 		RequestQuery:     request_query,
 	}
 	statusJSON, _ := json.Marshal(req)
-	resp, err := http.Post(nftMarketUrl+"/api/v1/action/actionGetAccountAssets", "application/json", bytes.NewReader(statusJSON))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespGetAccountAssets{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpPostJSON(ctx, cfg, cfg.NftMarketUrl+"/api/v1/action/actionGetAccountAssets", statusJSON, result); err != nil {
 		return nil, err
 	}
 	//get collections status = 1
@@ -290,10 +271,15 @@ func GetAccountNFTs(AccountIndex int64) (*RespGetAccountAssets, error) {
 }
 
 func GetAccountOffers(AccountIndex int64) (*RespGetAccountOffers, error) {
-	request_query := fmt.Sprintf("query MyQuery {\n  actionGetAccountOffers(account_index: %d) {\n    confirmedOfferIdList\n    pendingOffers {\n      account_name\n      asset_id\n      created_at\n      direction\n      expired_at\n      id\n      payment_asset_amount\n      payment_asset_id\n      signature\n      status\n    }\n  }\n}", AccountIndex)
+	return GetAccountOffersWithContext(context.Background(), AccountIndex)
+}
+
+func GetAccountOffersWithContext(ctx context.Context, AccountIndex int64) (*RespGetAccountOffers, error) {
+	cfg := GetConfig()
+	request_query := fmt.Sprintf("query MyQuery {\n  actionGetAccountOffers(account_index: %d) {\n    confirmedOfferIdList\n    pendingOffers {\n      account_name\n      asset_id\n      created_at\n      direction\n      expired_at\n      id\n      l2_offer_id\n      payment_asset_amount\n      payment_asset_id\n      signature\n      status\n    }\n  }\n}", AccountIndex)
 	input := InputGetAccountOffersActionBody{AccountIndex: AccountIndex}
 	action := ActionBody{Name: "actionGetAccountOffers"}
-	SessionVariables := SessionVariablesBody{XHasuraUserId: "x-hasura-role", XHasuraRole: "admin"}
+	SessionVariables := cfg.sessionVariables()
 	req := ReqGetAccountOffers{
 		Input:            input,
 		Action:           action,
@@ -301,20 +287,8 @@ func GetAccountOffers(AccountIndex int64) (*RespGetAccountOffers, error) {
 		RequestQuery:     request_query,
 	}
 	statusJSON, _ := json.Marshal(req)
-	resp, err := http.Post(nftMarketUrl+"/api/v1/action/actionGetAccountOffers", "application/json", bytes.NewReader(statusJSON))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespGetAccountOffers{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpPostJSON(ctx, cfg, cfg.NftMarketUrl+"/api/v1/action/actionGetAccountOffers", statusJSON, result); err != nil {
 		return nil, err
 	}
 	//get collections status = 1
@@ -322,10 +296,15 @@ func GetAccountOffers(AccountIndex int64) (*RespGetAccountOffers, error) {
 }
 
 func GetNftOffers(NftId int64) (*RespGetAssetOffers, error) {
-	request_query := fmt.Sprintf("query MyQuery {\n  actionGetAssetOffers(asset_id: %d) {\n    confirmedOfferIdList\n    pendingOffers {\n      account_name\n      asset_id\n      created_at\n      direction\n      expired_at\n      id\n      payment_asset_amount\n      payment_asset_id\n      signature\n      status\n    }\n  }\n}", NftId)
+	return GetNftOffersWithContext(context.Background(), NftId)
+}
+
+func GetNftOffersWithContext(ctx context.Context, NftId int64) (*RespGetAssetOffers, error) {
+	cfg := GetConfig()
+	request_query := fmt.Sprintf("query MyQuery {\n  actionGetAssetOffers(asset_id: %d) {\n    confirmedOfferIdList\n    pendingOffers {\n      account_name\n      asset_id\n      created_at\n      direction\n      expired_at\n      id\n      l2_offer_id\n      payment_asset_amount\n      payment_asset_id\n      signature\n      status\n    }\n  }\n}", NftId)
 	input := InputGetAssetOffersActionBody{AssetId: NftId}
 	action := ActionBody{Name: "actionGetAssetOffers"}
-	SessionVariables := SessionVariablesBody{XHasuraUserId: "x-hasura-role", XHasuraRole: "admin"}
+	SessionVariables := cfg.sessionVariables()
 	req := ReqGetAssetOffers{
 		Input:            input,
 		Action:           action,
@@ -333,20 +312,8 @@ func GetNftOffers(NftId int64) (*RespGetAssetOffers, error) {
 		RequestQuery:     request_query,
 	}
 	statusJSON, _ := json.Marshal(req)
-	resp, err := http.Post(nftMarketUrl+"/api/v1/action/actionGetAssetOffers", "application/json", bytes.NewReader(statusJSON))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespGetAssetOffers{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpPostJSON(ctx, cfg, cfg.NftMarketUrl+"/api/v1/action/actionGetAssetOffers", statusJSON, result); err != nil {
 		return nil, err
 	}
 	//get collections status = 1
@@ -354,10 +321,18 @@ func GetNftOffers(NftId int64) (*RespGetAssetOffers, error) {
 }
 
 func GetNftById(nftId int64) (*RespetAssetByAssetId, error) {
+	return GetNftByIdWithContext(context.Background(), nftId)
+}
+
+func GetNftByIdWithContext(ctx context.Context, nftId int64) (*RespetAssetByAssetId, error) {
+	return getNftById(ctx, GetConfig(), nftId)
+}
+
+func getNftById(ctx context.Context, cfg *Config, nftId int64) (*RespetAssetByAssetId, error) {
 	request_query := fmt.Sprintf("query MyQuery {\n  actionGetAssetByAssetId(asset_id: %d) {\n    asset {\n      account_name\n      audio_thumb\n      collection_id\n      content_hash\n      created_at\n      creator_earning_rate\n      description\n      expired_at\n      id\n      image_thumb\n      levels\n      media\n      name\n      nft_index\n      properties\n      stats\n      status\n      video_thumb\n    }\n  }\n}\n", nftId)
 	input := InputGetAssetByIdActionBody{AssetId: nftId}
 	action := ActionBody{Name: "actionGetAssetByAssetId"}
-	SessionVariables := SessionVariablesBody{XHasuraUserId: "x-hasura-role", XHasuraRole: "admin"}
+	SessionVariables := cfg.sessionVariables()
 	req := ReqGetAssetById{
 		Input:            input,
 		Action:           action,
@@ -365,73 +340,56 @@ func GetNftById(nftId int64) (*RespetAssetByAssetId, error) {
 		RequestQuery:     request_query,
 	}
 	statusJSON, _ := json.Marshal(req)
-	resp, err := http.Post(nftMarketUrl+"/api/v1/action/actionGetAssetByAssetId", "application/json", bytes.NewReader(statusJSON))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
 	result := &RespetAssetByAssetId{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpPostJSON(ctx, cfg, cfg.NftMarketUrl+"/api/v1/action/actionGetAssetByAssetId", statusJSON, result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
 func GetNextOfferId(AccountName string) (*RespGetNextOfferId, error) {
-	resp, err := http.Get(nftMarketUrl + fmt.Sprintf("/api/v1/offer/getNextOfferId?account_name=%s", AccountName))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
+	return GetNextOfferIdWithContext(context.Background(), AccountName)
+}
+
+func GetNextOfferIdWithContext(ctx context.Context, AccountName string) (*RespGetNextOfferId, error) {
+	return getNextOfferId(ctx, GetConfig(), AccountName)
+}
+
+func getNextOfferId(ctx context.Context, cfg *Config, AccountName string) (*RespGetNextOfferId, error) {
 	result := &RespGetNextOfferId{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpGet(ctx, cfg, cfg.NftMarketUrl+fmt.Sprintf("/api/v1/offer/getNextOfferId?account_name=%s", AccountName), result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
 func GetOfferById(OfferId int64) (*RespGetOfferByOfferId, error) {
-	resp, err := http.Get(nftMarketUrl + fmt.Sprintf("/api/v1/offer/getOfferByOfferId?offer_id=%d", OfferId))
+	return GetOfferByIdWithContext(context.Background(), OfferId)
+}
 
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
-	}
+func GetOfferByIdWithContext(ctx context.Context, OfferId int64) (*RespGetOfferByOfferId, error) {
+	return getOfferById(ctx, GetConfig(), OfferId)
+}
+
+func getOfferById(ctx context.Context, cfg *Config, OfferId int64) (*RespGetOfferByOfferId, error) {
 	result := &RespGetOfferByOfferId{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := httpGet(ctx, cfg, cfg.NftMarketUrl+fmt.Sprintf("/api/v1/offer/getOfferByOfferId?offer_id=%d", OfferId), result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
 func GetListingOffers(isSell int64) (*RespGetNftBeingSell, error) {
+	return GetListingOffersWithContext(context.Background(), isSell)
+}
+
+func GetListingOffersWithContext(ctx context.Context, isSell int64) (*RespGetNftBeingSell, error) {
 	queryStr := fmt.Sprintf(`
 {"query":"query MyQuery {\n  offer(where: {status: {_eq: \"%d\"}, direction: {_eq: \"1\"}}) {\n    id\n    l2_offer_id\n    asset_id\n    counterpart_id\n    payment_asset_id\n    payment_asset_amount\n    signature\n    status\n    direction\n    expired_at\n   created_at\n    asset {\n      id\n      nft_index\n      name\n      collection_id\n      content_hash\n      create_tx_hash\n      creator_earning_rate\n      description\n      expired_at\n      image_thumb\n      l1_token_id\n      last_payment_asset_amount\n      last_payment_asset_id\n      media_detail {\n        url\n      }\n      nft_url\n      status\n      video_thumb\n      asset_stats {\n        max_value\n        key\n      }\n      asset_properties {\n        key\n        value\n      }\n      asset_levels {\n        key\n        max_value\n        value\n      }\n    }\n  }\n}\n","variables":{}}
 `, isSell)
 
 	var data = []byte(queryStr)
-	body, err := Post2Hasura(data)
+	body, err := Post2HasuraWithContext(ctx, data)
 	if err != nil {
 		return nil, err
 	}
@@ -444,75 +402,102 @@ func GetListingOffers(isSell int64) (*RespGetNftBeingSell, error) {
 }
 
 func Post2Hasura(data []byte) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodPost, hasuraUrl, bytes.NewReader(data))
-	if err != nil {
-		return []byte(""), err
+	return Post2HasuraWithContext(context.Background(), data)
+}
+
+func Post2HasuraWithContext(ctx context.Context, data []byte) ([]byte, error) {
+	return post2Hasura(ctx, GetConfig(), data)
+}
+
+// post2Hasura sends a GraphQL request. Queries are retried like the other
+// reads, mutations are sent once as they may apply twice.
+func post2Hasura(ctx context.Context, cfg *Config, data []byte) ([]byte, error) {
+	if cfg.HasuraTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Second*time.Duration(cfg.HasuraTimeout))
+		defer cancel()
+	}
+	var body []byte
+	send := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.HasuraUrl, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		cred, err := cfg.hasuraCredential(ctx)
+		if err != nil {
+			return err
+		}
+		cred.apply(req.Header)
+		body, err = sendRaw(cfg, req)
+		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-hasura-access-key", hasuraAdminKey)
-	hc := http.DefaultClient
-	hc.Timeout = time.Second * hasuraTimeDeadline
-	resp, err := hc.Do(req)
-	if err != nil {
-		return []byte(""), err
+	var err error
+	if isGraphQLQuery(data) {
+		err = withRetry(ctx, cfg, nil, send)
+	} else {
+		err = send()
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return []byte(""), err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return []byte(""), fmt.Errorf(string(body))
-	}
 	return body, nil
 }
 
+// isGraphQLQuery reports whether data holds only GraphQL queries, which are
+// safe to send twice. Anything it can not tell apart from a mutation is not.
+func isGraphQLQuery(data []byte) bool {
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return false
+	}
+	doc := strings.TrimSpace(payload.Query)
+	if strings.Contains(doc, "mutation") || strings.Contains(doc, "subscription") {
+		return false
+	}
+	return strings.HasPrefix(doc, "{") || strings.HasPrefix(doc, "query")
+}
+
 func UploadMedia(filePath string) (*RespMediaUpload, error) {
-	uri := fmt.Sprintf(nftMarketUrl+"%s", "/api/v1/asset/media")
+	return UploadMediaWithContext(context.Background(), filePath)
+}
+
+func UploadMediaWithContext(ctx context.Context, filePath string) (*RespMediaUpload, error) {
+	cfg := GetConfig()
+	uri := fmt.Sprintf(cfg.NftMarketUrl+"%s", "/api/v1/asset/media")
 	paramName := "image"
 	file, err := os.Open(filePath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer file.Close()
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	part, err := writer.CreateFormFile(paramName, filePath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	_, err = io.Copy(part, file)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	err = writer.Close()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	request, err := http.NewRequest("POST", uri, body)
+	ctx, cancel := context.WithTimeout(ctx, mediaUploadTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, body)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	request.Header.Set("Content-Type", writer.FormDataContentType())
-	t := http.DefaultTransport.(*http.Transport).Clone()
-	t.MaxIdleConns = 100
-	t.MaxConnsPerHost = 100
-	t.MaxIdleConnsPerHost = 100
-	clt := http.Client{
-		Timeout:   10 * time.Second,
-		Transport: t,
-	}
-	defer clt.CloseIdleConnections()
-	res, err := clt.Do(request)
-	defer func() {
-		res.Body.Close()
-	}()
-	if err != nil {
-		panic(fmt.Errorf("err is %s", err.Error()))
-	}
-	body1, err1 := ioutil.ReadAll(res.Body)
-	if err1 != nil {
-		panic(fmt.Errorf("ioutil.ReadAll  err is %s", err1.Error()))
+	// uploads are not retried, the body is consumed by the first attempt
+	body1, err := sendRaw(cfg, request)
+	if err != nil {
+		return nil, err
 	}
 	result := &RespMediaUpload{}
 	if err := json.Unmarshal(body1, &result); err != nil {
@@ -521,57 +506,46 @@ func UploadMedia(filePath string) (*RespMediaUpload, error) {
 	return result, nil
 }
 
-//newZecreyMarketplaceClientDefault private
-func newZecreyMarketplaceClientWithSeed(accountName, seed string) (*client, error) {
+//newZecreyMarketplaceClientWithSeed private
+func newZecreyMarketplaceClientWithSeed(cfg *Config, accountName, seed string) (*client, error) {
 	keyManager, err := NewSeedKeyManager(seed)
 	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("wrong seed:%s", seed))
+		// the error of the key derivation may quote the seed
+		return nil, errors.New("invalid seed")
 	}
 	l2pk := eddsaHelper.GetEddsaPublicKey(seed[2:])
-	connEth, err := _rpc.NewClient(chainRpcUrl)
+	connEth, err := dialChain(context.Background(), cfg)
 	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("wrong rpc url:%s", chainRpcUrl))
+		return nil, err
 	}
 	return &client{
+		cfg:            cfg,
 		accountName:    fmt.Sprintf("%s%s", accountName, NameSuffix),
 		seed:           seed,
 		l2pk:           l2pk,
-		nftMarketUrl:   nftMarketUrl,
-		legendUrl:      legendUrl,
+		nftMarketUrl:   cfg.NftMarketUrl,
+		legendUrl:      cfg.LegendUrl,
 		providerClient: connEth,
 		keyManager:     keyManager,
-	}, nil
-}
-
-//newZecreyMarketplaceClientDefault private
-func newZecreyMarketplaceClientDefault(accountName string) (*client, error) {
-	connEth, err := _rpc.NewClient(chainRpcUrl)
-	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("wrong rpc url:%s", chainRpcUrl))
-	}
-	return &client{
-		accountName:    fmt.Sprintf("%s%s", accountName, NameSuffix),
-		nftMarketUrl:   nftMarketUrl,
-		legendUrl:      legendUrl,
-		providerClient: connEth,
+		ownsKey:        true,
 	}, nil
 }
 
 func CreateL1Account() (l1Addr, privateKeyStr, l2pk, seed string, err error) {
 	privateKey, err := crypto.GenerateKey()
 	if err != nil {
-		logx.Errorf("[CreateL1Account] GenerateKey err: %s", err)
+		GetConfig().logger().Error("[CreateL1Account] GenerateKey failed", "err", err)
 		return "", "", "", "", err
 	}
 	privateKeyStr = hex.EncodeToString(crypto.FromECDSA(privateKey))
 	l1Addr, err = ecdsaHelper.GenerateL1Address(privateKey)
 	if err != nil {
-		logx.Errorf("[CreateL1Account] GenerateL1Address err: %s", err)
+		GetConfig().logger().Error("[CreateL1Account] GenerateL1Address failed", "err", err)
 		return "", "", "", "", err
 	}
 	seed, err = eddsaHelper.GetEddsaSeed(privateKey)
 	if err != nil {
-		logx.Errorf("[CreateL1Account] GetEddsaSeed err: %s", err)
+		GetConfig().logger().Error("[CreateL1Account] GetEddsaSeed failed", "err", err)
 		return "", "", "", "", err
 	}
 	l2pk = eddsaHelper.GetEddsaPublicKey(seed[2:])
@@ -582,7 +556,7 @@ func GetSeedAndL2Pk(privateKeyStr string) (l2pk, seed string, err error) {
 	privECDSA, err := crypto.ToECDSA(common.FromHex(privateKeyStr))
 	seed, err = eddsaHelper.GetEddsaSeed(privECDSA)
 	if err != nil {
-		logx.Errorf("[CreateL1Account] GetEddsaSeed err: %s", err)
+		GetConfig().logger().Error("[GetSeedAndL2Pk] GetEddsaSeed failed", "err", err)
 		return "", "", err
 	}
 	l2pk = eddsaHelper.GetEddsaPublicKey(seed[2:])
@@ -590,86 +564,155 @@ func GetSeedAndL2Pk(privateKeyStr string) (l2pk, seed string, err error) {
 }
 
 func RegisterAccountWithPrivateKey(accountName, l1Addr, privateKey string) (ZecreyNftMarketSDK, error) {
+	return RegisterAccountWithPrivateKeyWithContext(context.Background(), accountName, l1Addr, privateKey)
+}
+
+func RegisterAccountWithPrivateKeyWithContext(ctx context.Context, accountName, l1Addr, privateKey string) (ZecreyNftMarketSDK, error) {
 	l2pk, seed, err := GetSeedAndL2Pk(privateKey)
 	if err != nil {
 		return nil, err
 	}
-	c, err := newZecreyMarketplaceClientWithSeed(accountName, seed)
+	privECDSA, err := crypto.ToECDSA(common.FromHex(privateKey))
 	if err != nil {
 		return nil, err
 	}
-	if ok, err := IfAccountRegistered(accountName); ok {
-		if err != nil {
-			return nil, err
-		}
-		return NewZecreyMarketplaceClient(accountName, seed)
-	}
-	var chainId *big.Int
-	chainId, err = c.providerClient.ChainID(context.Background())
+	signer, err := NewPrivateKeyL1Signer(privECDSA)
 	if err != nil {
 		return nil, err
 	}
-	authCli, err := _rpc.NewAuthClient(c.providerClient, privateKey, chainId)
-	if err != nil {
+	if err := registerAccount(ctx, GetConfig(), accountName, common.HexToAddress(l1Addr), signer, l2pk); err != nil {
 		return nil, err
 	}
-	px, py, err := zecreyLegendUtil.PubKeyStrToPxAndPy(l2pk)
-	if err != nil {
+	return NewZecreyMarketplaceClient(accountName, seed)
+}
+
+// RegisterAccountWithL1Signer registers accountName, owned by l1Addr, paying the
+// registration with signer, and returns the client signing with key.
+func RegisterAccountWithL1Signer(accountName, l1Addr string, signer L1Signer, key KeyManager) (ZecreyNftMarketSDK, error) {
+	return RegisterAccountWithL1SignerWithContext(context.Background(), accountName, l1Addr, signer, key)
+}
+
+func RegisterAccountWithL1SignerWithContext(ctx context.Context, accountName, l1Addr string, signer L1Signer, key KeyManager) (ZecreyNftMarketSDK, error) {
+	cfg := GetConfig()
+	if err := registerAccount(ctx, cfg, accountName, common.HexToAddress(l1Addr), signer, l2pkOfKey(key)); err != nil {
 		return nil, err
 	}
+	return NewZecreyMarketplaceClientWithKeyManager(cfg, accountName, key)
+}
+
+// registerAccount registers accountName unless it is registered already.
+func registerAccount(ctx context.Context, cfg *Config, accountName string, owner common.Address, signer L1Signer, l2pk string) error {
+	ok, err := ifAccountRegistered(ctx, cfg, accountName)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	providerClient, err := dialChain(ctx, cfg)
+	if err != nil {
+		return err
+	}
 	//get base contract address
-	resp, err := GetLayer2BasicInfo()
+	resp, err := getLayer2BasicInfo(ctx, cfg)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	ZecreyLegendContract := resp.ContractAddresses[0]
 	ZnsPriceOracle := resp.ContractAddresses[1]
+	_, err = RegisterZNS(ctx, providerClient, signer,
+		common.HexToAddress(ZecreyLegendContract), common.HexToAddress(ZnsPriceOracle),
+		accountName, owner, l2pk)
+	return err
+}
 
-	gasPrice, err := c.providerClient.SuggestGasPrice(context.Background())
+// RegisterZNS sends the L1 transaction registering accountName, owned by owner
+// and paid by signer, to the zecrey legend contract on backend.
+func RegisterZNS(ctx context.Context, backend L1Backend, signer L1Signer,
+	zecreyLegendContract, znsPriceOracle common.Address,
+	accountName string, owner common.Address, l2pk string,
+) (txHash string, err error) {
+	px, py, err := zecreyLegendUtil.PubKeyStrToPxAndPy(l2pk)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	zecreyInstance, err := zecreyLegendRpc.LoadZecreyLegendInstance(c.providerClient, ZecreyLegendContract)
+	zecreyInstance, err := zecreyLegendRpc.NewZecreyLegend(zecreyLegendContract, backend)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	priceOracleInstance, err := zecreyLegendRpc.LoadStablePriceOracleInstance(c.providerClient, ZnsPriceOracle)
+	priceOracleInstance, err := zecreyLegendRpc.NewStablePriceOracle(znsPriceOracle, backend)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	_, err = zecreyLegendRpc.RegisterZNS(c.providerClient, authCli,
-		zecreyInstance, priceOracleInstance,
-		gasPrice, DefaultGasLimit, accountName,
-		common.HexToAddress(l1Addr), px, py)
+	amount, err := priceOracleInstance.Price(&bind.CallOpts{Context: ctx}, accountName)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return NewZecreyMarketplaceClient(accountName, seed)
+	opts, err := transactOpts(ctx, backend, signer, nil, DefaultGasLimit)
+	if err != nil {
+		return "", err
+	}
+	opts.Value = amount
+	tx, err := zecreyInstance.RegisterZNS(opts, accountName, owner, px, py)
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash().String(), nil
 }
 
 func ApplyRegisterHost(
 	accountName string, l2Pk string, OwnerAddr string) (*RespApplyRegisterHost, error) {
-	resp, err := http.PostForm(legendUrl+"/api/v1/register/applyRegisterHost",
+	return ApplyRegisterHostWithContext(context.Background(), accountName, l2Pk, OwnerAddr)
+}
+
+func ApplyRegisterHostWithContext(ctx context.Context, accountName string, l2Pk string, OwnerAddr string) (*RespApplyRegisterHost, error) {
+	cfg := GetConfig()
+	result := &RespApplyRegisterHost{}
+	err := httpPostForm(ctx, cfg, cfg.LegendUrl+"/api/v1/register/applyRegisterHost",
 		url.Values{
 			"account_name": {accountName},
 			"l2_pk":        {l2Pk},
-			"owner_addr":   {OwnerAddr}})
+			"owner_addr":   {OwnerAddr}},
+		result)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	return result, nil
+}
+
+// dialChain connects to the L1 rpc of cfg, sharing its HTTP client for http(s) urls.
+func dialChain(ctx context.Context, cfg *Config) (*_rpc.ProviderClient, error) {
+	if isHTTPURL(cfg.ChainRpcUrl) {
+		// the client fails over between the http endpoints on every call
+		rpcClient, err := rpc.DialHTTPWithClient(cfg.ChainRpcUrl, cfg.clientFor(FamilyL1RPC))
+		if err != nil {
+			return nil, fmt.Errorf("wrong rpc url:%s", cfg.ChainRpcUrl)
+		}
+		return &_rpc.ProviderClient{Client: ethclient.NewClient(rpcClient)}, nil
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(body))
+	urls := cfg.endpointsOf(FamilyL1RPC)
+	pool := cfg.endpoints(FamilyL1RPC)
+	order := []int{0}
+	if pool != nil {
+		order = pool.order()
 	}
-	result := &RespApplyRegisterHost{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+	var lastErr error
+	for _, i := range order {
+		rpcClient, err := rpc.DialContext(ctx, urls[i])
+		if err != nil {
+			lastErr = err
+			if pool != nil {
+				pool.markDown(i)
+			}
+			continue
+		}
+		if pool != nil {
+			pool.markUp(i)
+		}
+		return &_rpc.ProviderClient{Client: ethclient.NewClient(rpcClient)}, nil
 	}
-	return result, nil
+	cfg.logger().Error("[dialChain] failed", "err", lastErr)
+	return nil, fmt.Errorf("wrong rpc url:%s", cfg.ChainRpcUrl)
 }
 
 func BytesToAddress(b []byte) common.Address {
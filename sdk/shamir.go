@@ -0,0 +1,268 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	seedSharePrefix  = "zshare1:"
+	seedShareVersion = 1
+	seedShareIdSize  = 4
+	checksumSize     = 4
+)
+
+var (
+	ErrInvalidSeedShare = errors.New("invalid seed share")
+	// ErrSeedMismatch is returned when a recovered seed is not the one of the account.
+	ErrSeedMismatch = errors.New("recovered seed does not match the account")
+)
+
+var shareEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// SplitSeed splits the L2 seed, and the L1 private key when not empty, into
+// count Shamir shares of which any threshold recover them. When seed is empty
+// it is derived from l1PrivateKey like GetSeedAndL2Pk. The shares are
+// printable strings, each with its own checksum.
+func SplitSeed(seed, l1PrivateKey string, threshold, count int) ([]string, error) {
+	if threshold < 2 || threshold > count || count > 255 {
+		return nil, fmt.Errorf("invalid %d of %d shares", threshold, count)
+	}
+	if seed == "" && l1PrivateKey == "" {
+		return nil, errors.New("seed or l1 private key is required")
+	}
+	if l1PrivateKey != "" {
+		if _, err := crypto.ToECDSA(common.FromHex(l1PrivateKey)); err != nil {
+			return nil, fmt.Errorf("invalid l1 private key: %v", err)
+		}
+		_, l1Seed, err := GetSeedAndL2Pk(l1PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if seed == "" {
+			seed = l1Seed
+		} else if seed != l1Seed {
+			return nil, errors.New("seed is not derived from the l1 private key")
+		}
+	}
+	if _, err := l2pkOfSeed(seed); err != nil {
+		return nil, err
+	}
+	if len(seed) > 255 || len(l1PrivateKey) > 255 {
+		return nil, errors.New("seed or l1 private key too long")
+	}
+
+	secret := []byte{seedShareVersion, byte(len(seed))}
+	secret = append(secret, seed...)
+	secret = append(secret, byte(len(l1PrivateKey)))
+	secret = append(secret, l1PrivateKey...)
+	secret = append(secret, checksum(secret)...)
+	defer wipe(secret)
+
+	id := make([]byte, seedShareIdSize)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	data := make([][]byte, count)
+	for i := range data {
+		data[i] = make([]byte, len(secret))
+	}
+	coeffs := make([]byte, threshold)
+	defer wipe(coeffs)
+	for pos, b := range secret {
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		coeffs[0] = b
+		for i := range data {
+			data[i][pos] = gfEval(coeffs, byte(i+1))
+		}
+	}
+	shares := make([]string, count)
+	for i := range data {
+		share := append(append([]byte{}, id...), byte(threshold), byte(i+1))
+		share = append(share, data[i]...)
+		share = append(share, checksum(share)...)
+		shares[i] = seedSharePrefix + strings.ToLower(shareEncoding.EncodeToString(share))
+	}
+	return shares, nil
+}
+
+// CombineSeedShares recovers the seed and the L1 private key, empty when it
+// was not split, from a quorum of shares of SplitSeed.
+func CombineSeedShares(shares []string) (seed, l1PrivateKey string, err error) {
+	var (
+		id        []byte
+		threshold int
+		xs        []byte
+		ys        [][]byte
+	)
+	for n, s := range shares {
+		share, err := decodeSeedShare(s)
+		if err != nil {
+			return "", "", fmt.Errorf("share %d: %w", n+1, err)
+		}
+		shareId, shareThreshold, x, y := share[:seedShareIdSize], int(share[seedShareIdSize]), share[seedShareIdSize+1], share[seedShareIdSize+2:]
+		if id == nil {
+			id, threshold = shareId, shareThreshold
+		} else if !bytes.Equal(id, shareId) || threshold != shareThreshold || len(y) != len(ys[0]) {
+			return "", "", fmt.Errorf("share %d: %w: not from the same split", n+1, ErrInvalidSeedShare)
+		}
+		if bytes.IndexByte(xs, x) >= 0 {
+			continue
+		}
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+	if len(xs) == 0 || len(xs) < threshold {
+		return "", "", fmt.Errorf("%w: %d of %d shares", ErrInvalidSeedShare, len(xs), threshold)
+	}
+	xs, ys = xs[:threshold], ys[:threshold]
+
+	secret := make([]byte, len(ys[0]))
+	defer wipe(secret)
+	for i := range xs {
+		// lagrange basis of x_i at 0
+		basis := byte(1)
+		for j := range xs {
+			if i != j {
+				basis = gfMul(basis, gfDiv(xs[j], xs[j]^xs[i]))
+			}
+		}
+		for pos := range secret {
+			secret[pos] ^= gfMul(ys[i][pos], basis)
+		}
+	}
+
+	body := secret[:len(secret)-checksumSize]
+	if len(body) < 3 || !bytes.Equal(checksum(body), secret[len(body):]) || body[0] != seedShareVersion {
+		return "", "", fmt.Errorf("%w: recovered secret is corrupted", ErrInvalidSeedShare)
+	}
+	seedLen := int(body[1])
+	if 2+seedLen >= len(body) || 3+seedLen+int(body[2+seedLen]) != len(body) {
+		return "", "", fmt.Errorf("%w: recovered secret is corrupted", ErrInvalidSeedShare)
+	}
+	return string(body[2 : 2+seedLen]), string(body[3+seedLen:]), nil
+}
+
+// RecoverSeedKeyManager combines shares like CombineSeedShares and verifies
+// that the recovered seed is the one of the AccountPk of accountName before
+// returning its key.
+func RecoverSeedKeyManager(accountName string, shares []string) (KeyManager, error) {
+	return RecoverSeedKeyManagerWithContext(context.Background(), accountName, shares)
+}
+
+func RecoverSeedKeyManagerWithContext(ctx context.Context, accountName string, shares []string) (KeyManager, error) {
+	seed, l1PrivateKey, err := CombineSeedShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	if l1PrivateKey != "" {
+		_, l1Seed, err := GetSeedAndL2Pk(l1PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if l1Seed != seed {
+			return nil, fmt.Errorf("%w: seed is not derived from the l1 private key", ErrSeedMismatch)
+		}
+	}
+	l2pk, err := l2pkOfSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	account, err := GetAccountByAccountNameWithContext(ctx, accountName)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimPrefix(account.Account.AccountPk, "0x"), l2pk) {
+		return nil, fmt.Errorf("%w: %s", ErrSeedMismatch, accountName)
+	}
+	return NewSeedKeyManager(seed)
+}
+
+func decodeSeedShare(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, seedSharePrefix) {
+		return nil, fmt.Errorf("%w: missing %q prefix", ErrInvalidSeedShare, seedSharePrefix)
+	}
+	share, err := shareEncoding.DecodeString(strings.ToUpper(s[len(seedSharePrefix):]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSeedShare, err)
+	}
+	if len(share) < seedShareIdSize+3+checksumSize {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidSeedShare)
+	}
+	body := share[:len(share)-checksumSize]
+	if !bytes.Equal(checksum(body), share[len(body):]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidSeedShare)
+	}
+	if body[seedShareIdSize] < 2 || body[seedShareIdSize+1] == 0 {
+		return nil, fmt.Errorf("%w: invalid header", ErrInvalidSeedShare)
+	}
+	return body, nil
+}
+
+func checksum(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	return sum[:checksumSize]
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// GF(2^8) arithmetic with the AES polynomial x^8+x^4+x^3+x+1.
+var (
+	gfExpTable [510]byte
+	gfLogTable [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExpTable[i] = x
+		gfLogTable[x] = byte(i)
+		// multiply by the generator x+1
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	copy(gfExpTable[255:], gfExpTable[:255])
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExpTable[int(gfLogTable[a])+int(gfLogTable[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExpTable[int(gfLogTable[a])+255-int(gfLogTable[b])]
+}
+
+// gfEval evaluates the polynomial of coeffs, lowest degree first, at x.
+func gfEval(coeffs []byte, x byte) byte {
+	y := byte(0)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ coeffs[i]
+	}
+	return y
+}
@@ -0,0 +1,91 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSeedShares(t *testing.T) {
+	l1Key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l1PrivateKey := hexutil.Encode(crypto.FromECDSA(l1Key))
+	l2pk, seed, err := GetSeedAndL2Pk(l1PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares, err := SplitSeed("", l1PrivateKey, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, quorum := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4, 0}} {
+		var picked []string
+		for _, i := range quorum {
+			picked = append(picked, shares[i])
+		}
+		gotSeed, gotL1, err := CombineSeedShares(picked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotSeed != seed || gotL1 != l1PrivateKey {
+			t.Fatalf("quorum %v recovered another secret", quorum)
+		}
+	}
+	if _, _, err := CombineSeedShares(shares[:2]); !errors.Is(err, ErrInvalidSeedShare) {
+		t.Fatalf("recovered from 2 of 3 shares: %v", err)
+	}
+	if _, _, err := CombineSeedShares([]string{shares[0], shares[0], shares[1]}); !errors.Is(err, ErrInvalidSeedShare) {
+		t.Fatalf("recovered from a duplicated share: %v", err)
+	}
+	typo := []byte(shares[1])
+	typo[len(typo)-3] ^= 1
+	if _, _, err := CombineSeedShares([]string{shares[0], string(typo), shares[2]}); !errors.Is(err, ErrInvalidSeedShare) {
+		t.Fatalf("mistyped share accepted: %v", err)
+	}
+	other, err := SplitSeed("0x"+strings.Repeat("12", 32), "", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := CombineSeedShares([]string{shares[0], shares[1], other[0]}); !errors.Is(err, ErrInvalidSeedShare) {
+		t.Fatalf("shares of another split mixed: %v", err)
+	}
+	if _, err := SplitSeed("0x"+strings.Repeat("12", 32), l1PrivateKey, 2, 3); err == nil {
+		t.Fatal("split a seed not derived from the l1 key")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pk := l2pk
+		if r.URL.Query().Get("account_name") != "treasury" {
+			pk = strings.Repeat("00", 32)
+		}
+		fmt.Fprintf(w, `{"account": {"account_pk": "%s"}}`, pk)
+	}))
+	defer server.Close()
+	old := GetConfig()
+	defer SetConfig(old)
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	if err := SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	key, err := RecoverSeedKeyManager("treasury", shares[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l2pkOfKey(key) != l2pk {
+		t.Fatal("recovered key does not match the account")
+	}
+	if _, err := RecoverSeedKeyManager("someone", shares[2:]); !errors.Is(err, ErrSeedMismatch) {
+		t.Fatalf("recovered the key of another account: %v", err)
+	}
+}
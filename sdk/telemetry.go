@@ -0,0 +1,206 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/nonrecording"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Zecrey-Labs/zecrey-marketplace-go-sdk"
+
+// telemetry holds the tracer and the instruments of one tracer/meter provider pair.
+type telemetry struct {
+	enabled bool
+	tracer  trace.Tracer
+
+	operationDuration syncfloat64.Histogram
+	operationErrors   syncint64.Counter
+	requestDuration   syncfloat64.Histogram
+	requestErrors     syncint64.Counter
+	signatures        syncint64.Counter
+}
+
+type telemetryKey struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+var (
+	noopTelemetry = newTelemetry(nil, nil)
+
+	telemetries     = make(map[telemetryKey]*telemetry)
+	telemetriesLock sync.Mutex
+)
+
+// telemetry returns the tracer and instruments of cfg, which are no-ops unless
+// a TracerProvider or MeterProvider is configured.
+func (cfg *Config) telemetry() *telemetry {
+	if cfg.TracerProvider == nil && cfg.MeterProvider == nil {
+		return noopTelemetry
+	}
+	key := telemetryKey{tracerProvider: cfg.TracerProvider, meterProvider: cfg.MeterProvider}
+	if !isComparable(cfg.TracerProvider) || !isComparable(cfg.MeterProvider) {
+		return newTelemetry(cfg.TracerProvider, cfg.MeterProvider)
+	}
+	telemetriesLock.Lock()
+	defer telemetriesLock.Unlock()
+	t, ok := telemetries[key]
+	if !ok {
+		t = newTelemetry(cfg.TracerProvider, cfg.MeterProvider)
+		telemetries[key] = t
+	}
+	return t
+}
+
+func isComparable(v interface{}) bool {
+	return v == nil || reflect.TypeOf(v).Comparable()
+}
+
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) *telemetry {
+	t := &telemetry{enabled: tp != nil || mp != nil}
+	if tp == nil {
+		tp = trace.NewNoopTracerProvider()
+	}
+	if mp == nil {
+		mp = nonrecording.NewNoopMeterProvider()
+	}
+	t.tracer = tp.Tracer(instrumentationName)
+
+	// instruments that fail to register fall back to no-ops
+	noop := nonrecording.NewNoopMeter()
+	meter := mp.Meter(instrumentationName)
+	var err error
+	if t.operationDuration, err = meter.SyncFloat64().Histogram("zecrey.sdk.operation.duration",
+		instrument.WithUnit(unit.Milliseconds), instrument.WithDescription("Duration of SDK operations")); err != nil {
+		t.operationDuration, _ = noop.SyncFloat64().Histogram("")
+	}
+	if t.operationErrors, err = meter.SyncInt64().Counter("zecrey.sdk.operation.errors",
+		instrument.WithDescription("Failed SDK operations")); err != nil {
+		t.operationErrors, _ = noop.SyncInt64().Counter("")
+	}
+	if t.requestDuration, err = meter.SyncFloat64().Histogram("zecrey.sdk.request.duration",
+		instrument.WithUnit(unit.Milliseconds), instrument.WithDescription("Duration of HTTP requests by endpoint")); err != nil {
+		t.requestDuration, _ = noop.SyncFloat64().Histogram("")
+	}
+	if t.requestErrors, err = meter.SyncInt64().Counter("zecrey.sdk.request.errors",
+		instrument.WithDescription("Failed HTTP requests by endpoint")); err != nil {
+		t.requestErrors, _ = noop.SyncInt64().Counter("")
+	}
+	if t.signatures, err = meter.SyncInt64().Counter("zecrey.sdk.signatures",
+		instrument.WithDescription("Messages signed by the key manager")); err != nil {
+		t.signatures, _ = noop.SyncInt64().Counter("")
+	}
+	return t
+}
+
+type telemetryContextKey struct{}
+
+func telemetryFromContext(ctx context.Context) *telemetry {
+	if t, ok := ctx.Value(telemetryContextKey{}).(*telemetry); ok {
+		return t
+	}
+	return noopTelemetry
+}
+
+// operation is the span and the metrics of one high level SDK operation.
+type operation struct {
+	t     *telemetry
+	name  string
+	span  trace.Span
+	start time.Time
+}
+
+// startOperation starts the span of a high level operation such as MintNft.
+// Requests and signatures made with the returned ctx become its children.
+func (t *telemetry) startOperation(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, *operation) {
+	ctx = context.WithValue(ctx, telemetryContextKey{}, t)
+	ctx, span := t.tracer.Start(ctx, "zecrey."+name, trace.WithAttributes(attrs...))
+	return ctx, &operation{t: t, name: name, span: span, start: time.Now()}
+}
+
+// end finishes the operation, err is a pointer to the named error result of the caller.
+func (op *operation) end(err *error) {
+	attrs := []attribute.KeyValue{attribute.String("operation", op.name)}
+	if err != nil && *err != nil {
+		op.span.RecordError(*err)
+		op.span.SetStatus(codes.Error, (*err).Error())
+		op.t.operationErrors.Add(context.Background(), 1, attrs...)
+	}
+	op.t.operationDuration.Record(context.Background(), msSince(op.start), attrs...)
+	op.span.End()
+}
+
+// startStep starts a child span of the current operation, e.g. "sign".
+func (t *telemetry) startStep(ctx context.Context, name string) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+// traceRequest starts the client span of req and propagates the trace context
+// in its headers. The returned func records the outcome.
+func (t *telemetry) traceRequest(req *http.Request) (*http.Request, func(err error)) {
+	if !t.enabled {
+		return req, func(error) {}
+	}
+	endpoint := req.URL.Path
+	ctx, span := t.tracer.Start(req.Context(), requestStep(req),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.host", req.URL.Host),
+			attribute.String("http.target", endpoint)))
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	start := time.Now()
+	return req, func(err error) {
+		attrs := []attribute.KeyValue{attribute.String("endpoint", endpoint), attribute.String("method", req.Method)}
+		if err != nil {
+			status := 0
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				status = apiErr.StatusCode
+				span.SetAttributes(attribute.Int("http.status_code", status))
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			t.requestErrors.Add(context.Background(), 1, append(attrs, attribute.String("status_code", strconv.Itoa(status)))...)
+		}
+		t.requestDuration.Record(context.Background(), msSince(start), attrs...)
+		span.End()
+	}
+}
+
+// requestStep names the span of a request after the step of the operation it belongs to.
+func requestStep(req *http.Request) string {
+	switch {
+	case isPrepareRequest(req):
+		return "prepare"
+	case req.Method == http.MethodPost && req.Header.Get("Content-Type") == "application/x-www-form-urlencoded":
+		return "submit"
+	}
+	return "HTTP " + req.Method
+}
+
+func isPrepareRequest(req *http.Request) bool {
+	return strings.HasPrefix(req.URL.Path, "/api/v1/preparetx/")
+}
+
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
@@ -0,0 +1,70 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/signature"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type fixedKeyManager struct{}
+
+func (fixedKeyManager) Sign(message []byte, hFunc hash.Hash) ([]byte, error) {
+	return []byte("signature"), nil
+}
+
+func (fixedKeyManager) Public() signature.PublicKey {
+	return nil
+}
+
+func TestTelemetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/asset/createAsset" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"transtion": "{}"}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	err := func() (err error) {
+		ctx, op := cfg.telemetry().startOperation(context.Background(), "MintNft")
+		defer op.end(&err)
+		if err := httpGet(ctx, cfg, server.URL+"/api/v1/preparetx/getPrepareMintNftTxInfo", &RespetPreparetxInfo{}); err != nil {
+			return err
+		}
+		if _, err := withContext(ctx, fixedKeyManager{}).Sign([]byte("tx"), nil); err != nil {
+			return err
+		}
+		return httpPostForm(ctx, cfg, server.URL+"/api/v1/asset/createAsset", nil, &RespCreateAsset{})
+	}()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	root := spans[3]
+	if root.Name() != "zecrey.MintNft" || root.Status().Description == "" {
+		t.Fatalf("unexpected operation span: %s %v", root.Name(), root.Status())
+	}
+	for i, name := range []string{"prepare", "sign", "submit"} {
+		if spans[i].Name() != name || spans[i].Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Fatalf("unexpected child span %d: %s", i, spans[i].Name())
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	zecreyLegendUtil "github.com/zecrey-labs/zecrey-legend/common/util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultTxExpiry is how long the txs assembled by the SDK stay valid.
+const defaultTxExpiry = 6 * 24 * time.Hour
+
+func (c *client) Transfer(toAccountName string, assetId int64, amount *big.Int, memo string) (*RespSendTx, error) {
+	return c.TransferWithContext(context.Background(), toAccountName, assetId, amount, memo)
+}
+
+func (c *client) TransferWithContext(ctx context.Context, toAccountName string, assetId int64, amount *big.Int, memo string) (_ *RespSendTx, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "Transfer", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
+
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("transfer amount must be positive")
+	}
+	if toAccountName+NameSuffix == c.accountName {
+		return nil, errors.New("can not transfer to the own account")
+	}
+	fromAccountIndex, err := getAccountIndex(ctx, c.cfg, c.accountName)
+	if err != nil {
+		return nil, err
+	}
+	toAccountIndex, err := getAccountIndex(ctx, c.cfg, toAccountName+NameSuffix)
+	if err != nil {
+		return nil, err
+	}
+	toAccountNameHash, err := zecreyLegendUtil.ComputeAccountNameHash(toAccountName + NameSuffix)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := selectGasFee(ctx, c.cfg, TxTypeTransfer, assetId)
+	if err != nil {
+		return nil, err
+	}
+	tx := &TransferTxInfo{
+		FromAccountIndex:  fromAccountIndex,
+		ToAccountIndex:    toAccountIndex,
+		ToAccountNameHash: toAccountNameHash,
+		AssetId:           assetId,
+		AssetAmount:       amount,
+		GasAccountIndex:   fee.AccountIndex,
+		GasFeeAssetId:     fee.AssetId,
+		GasFeeAssetAmount: fee.Amount,
+		Memo:              memo,
+		CallDataHash:      callDataHash(""),
+		ExpiredAt:         time.Now().Add(defaultTxExpiry).UnixMilli(),
+	}
+	return c.sendTx(ctx, fromAccountIndex, tx, func(nonce int64) { tx.Nonce = nonce })
+}
+
+// sendTx signs tx with the next nonce of accountIndex, set by setNonce, and
+// submits it to the legend.
+func (c *client) sendTx(ctx context.Context, accountIndex int64, tx SignableTx, setNonce func(nonce int64)) (*RespSendTx, error) {
+	c.txLock.Lock()
+	defer c.txLock.Unlock()
+	nonce, err := getNextNonce(ctx, c.cfg, accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	setNonce(nonce)
+	var txInfo string
+	err = c.withKey(ctx, func(key KeyManager) (err error) {
+		txInfo, err = ConstructTx(key, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &RespSendTx{}
+	err = httpSubmitForm(ctx, c.cfg, c.cfg.LegendUrl+"/api/v1/tx/sendTx",
+		url.Values{
+			"tx_type": {fmt.Sprintf("%d", tx.TxType())},
+			"tx_info": {txInfo},
+		},
+		result)
+	if err != nil {
+		return nil, err
+	}
+	if tx, ok := tx.(feeTx); ok {
+		result.GasFee = tx.gasFee()
+	}
+	return result, nil
+}
+
+func callDataHash(callData string) []byte {
+	hFunc := mimc.NewMiMC()
+	hFunc.Write([]byte(callData))
+	return hFunc.Sum(nil)
+}
@@ -0,0 +1,89 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	zecreyLegendUtil "github.com/zecrey-labs/zecrey-legend/common/util"
+)
+
+func TestTransfer(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		lock  sync.Mutex
+		nonce int64
+		sent  []*TransferTxInfo
+	)
+	cfg := newLegendTestConfig(t, map[string]http.HandlerFunc{
+		"/api/v1/info/getGasFeeAssetList": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"assets": [{"asset_id": 0}, {"asset_id": 1}]}`))
+		},
+		"/api/v1/info/getGasFee": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"gas_fee": "1%s0"}`, r.URL.Query().Get("asset_id"))
+		},
+		"/api/v1/tx/getNextNonce": func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			fmt.Fprintf(w, `{"nonce": %d}`, nonce)
+		},
+		"/api/v1/tx/sendTx": func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			r.ParseForm()
+			tx := &TransferTxInfo{}
+			if r.Form.Get("tx_type") != fmt.Sprint(TxTypeTransfer) || json.Unmarshal([]byte(r.Form.Get("tx_info")), tx) != nil ||
+				VerifyTransferTx(l2pkOfKey(key), tx) != nil || tx.Nonce != nonce {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code": 400, "message": "invalid tx"}`))
+				return
+			}
+			nonce++
+			sent = append(sent, tx)
+			fmt.Fprintf(w, `{"tx_id": "tx%d"}`, tx.Nonce)
+		},
+	})
+	c := newClientWithKeyManager(cfg, nil, "alice", key, l2pkOfKey(key))
+
+	var wg sync.WaitGroup
+	for _, assetId := range []int64{1, 5} {
+		wg.Add(1)
+		go func(assetId int64) {
+			defer wg.Done()
+			if _, err := c.Transfer("bob", assetId, big.NewInt(1000), "rent"); err != nil {
+				t.Error(err)
+			}
+		}(assetId)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	if len(sent) != 2 || sent[0].Nonce != 0 || sent[1].Nonce != 1 {
+		t.Fatal("transfers not sent with consecutive nonces")
+	}
+	nameHash, _ := zecreyLegendUtil.ComputeAccountNameHash("bob" + NameSuffix)
+	for _, tx := range sent {
+		if tx.FromAccountIndex != 1 || tx.ToAccountIndex != 2 || tx.ToAccountNameHash != nameHash || tx.GasAccountIndex != 9 || tx.Memo != "rent" {
+			t.Fatalf("unexpected transfer %+v", tx)
+		}
+		// the fee is paid in the transferred asset when possible
+		feeAssetId := int64(0)
+		if tx.AssetId == 1 {
+			feeAssetId = 1
+		}
+		if tx.GasFeeAssetId != feeAssetId || tx.GasFeeAssetAmount.String() != fmt.Sprintf("1%d0", feeAssetId) {
+			t.Fatalf("unexpected fee %d of asset %d", tx.GasFeeAssetAmount, tx.GasFeeAssetId)
+		}
+	}
+	if _, err := c.Transfer("bob", 0, big.NewInt(0), ""); err == nil {
+		t.Fatal("zero transfer accepted")
+	}
+}
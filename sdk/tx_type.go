@@ -156,6 +156,17 @@ type WithdrawTxInfo struct {
 	Sig               []byte
 }
 
+type CancelOfferTxInfo struct {
+	AccountIndex      int64
+	OfferId           int64
+	GasAccountIndex   int64
+	GasFeeAssetId     int64
+	GasFeeAssetAmount *big.Int
+	ExpiredAt         int64
+	Nonce             int64
+	Sig               []byte
+}
+
 type RegisterZnsTxInfo struct {
 	TxType          uint8
 	AccountIndex    int64
@@ -62,8 +62,8 @@ type ActionBody struct {
 }
 
 type SessionVariablesBody struct {
-	XHasuraUserId string `json:"x-hasura-user-id,optional"`
-	XHasuraRole   string `json:"x-hasura-role,optional"`
+	XHasuraUserId string `json:"x-hasura-user-id,omitempty,optional"`
+	XHasuraRole   string `json:"x-hasura-role,omitempty,optional"`
 }
 
 type ReqGetActionStatus struct {
@@ -117,6 +117,8 @@ type Collection struct {
 
 type RespCreateCollection struct {
 	Collection Collection `json:"collection"`
+	// GasFee is the gas fee signed in the tx, set by the SDK.
+	GasFee *GasFee `json:"-"`
 }
 
 type RespUpdateCollection struct {
@@ -185,7 +187,8 @@ type NftInfo struct {
 }
 
 type RespCreateAsset struct {
-	Asset NftInfo `json:"asset"`
+	Asset  NftInfo `json:"asset"`
+	GasFee *GasFee `json:"-"`
 }
 
 type RespSearchAsset struct {
@@ -208,11 +211,13 @@ type ResqGetCollectionOwnerNum struct {
 }
 
 type ResqSendTransferNft struct {
-	Success bool `json:"success"`
+	Success bool    `json:"success"`
+	GasFee  *GasFee `json:"-"`
 }
 
 type ResqSendWithdrawNft struct {
-	Success bool `json:"success"`
+	Success bool    `json:"success"`
+	GasFee  *GasFee `json:"-"`
 }
 
 type RespGetNextOfferId struct {
@@ -246,7 +251,8 @@ type RespGetOfferByAccountNameAndAssetId struct {
 }
 
 type RespAcceptOffer struct {
-	Offer Offer `json:"offer"`
+	Offer  Offer   `json:"offer"`
+	GasFee *GasFee `json:"-"`
 }
 
 type RespCancelOffer struct {
@@ -515,3 +521,46 @@ type RespGetNftBeingSell struct {
 type RespGetNftBeingBuy struct {
 	Data *HasuraDataOffer `json:"data"`
 }
+
+type RespSendTx struct {
+	TxId   string  `json:"tx_id"`
+	GasFee *GasFee `json:"-"`
+}
+
+type RespGetGasAccount struct {
+	AccountStatus int64  `json:"account_status"`
+	AccountIndex  int64  `json:"account_index"`
+	AccountName   string `json:"account_name"`
+}
+
+type GasFeeAsset struct {
+	AssetId       int64  `json:"asset_id"`
+	AssetName     string `json:"asset_name"`
+	AssetDecimals int64  `json:"asset_decimals"`
+	AssetSymbol   string `json:"asset_symbol"`
+	AssetAddress  string `json:"asset_address"`
+	IsGasAsset    int64  `json:"is_gas_asset"`
+}
+
+type RespGetGasFeeAssetList struct {
+	Assets []*GasFeeAsset `json:"assets"`
+}
+
+type RespGetGasFee struct {
+	GasFee string `json:"gas_fee"`
+}
+
+type RespGetTxByHash struct {
+	Tx          Tx    `json:"tx"`
+	CommittedAt int64 `json:"committed_at"`
+	VerifiedAt  int64 `json:"verified_at"`
+	ExecutedAt  int64 `json:"executed_at"`
+}
+
+type RespGetMaxCollectionId struct {
+	CollectionId int64 `json:"collection_id"`
+}
+
+type RespGetMaxNftIndex struct {
+	MaxNftIndex int64 `json:"max_nft_index"`
+}
@@ -0,0 +1,164 @@
+package sdk
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+)
+
+// ErrInvalidSignature is returned, wrapped, when a tx is not signed by the expected key.
+var ErrInvalidSignature = errors.New("invalid tx signature")
+
+func VerifyCreateCollectionTx(accountPk string, tx *CreateCollectionTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+func VerifyMintNftTx(accountPk string, tx *MintNftTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+func VerifyTransferNftTx(accountPk string, tx *TransferNftTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+func VerifyWithdrawNftTx(accountPk string, tx *WithdrawNftTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+func VerifyOfferTx(accountPk string, tx *OfferTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+func VerifyCancelOfferTx(accountPk string, tx *CancelOfferTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+func VerifyTransferTx(accountPk string, tx *TransferTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+func VerifyWithdrawTx(accountPk string, tx *WithdrawTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+// VerifyAtomicMatchTx verifies the signature of the account submitting the
+// match only, verify the signatures of its offers with VerifyOfferTx.
+func VerifyAtomicMatchTx(accountPk string, tx *AtomicMatchTxInfo) error {
+	return VerifyTx(accountPk, tx)
+}
+
+// VerifyTx recomputes the message hash of a signed tx, e.g. parsed from the
+// output of the Construct*Tx functions, and checks its signature against
+// accountPk, the hex encoded public key of the signer as in
+// NftAccountInfo.AccountPk.
+func VerifyTx(accountPk string, tx SignableTx) error {
+	pk, err := parseAccountPk(accountPk)
+	if err != nil {
+		return err
+	}
+	_, msgHash, err := txMsgHash(tx)
+	if err != nil {
+		return err
+	}
+	sig := tx.Signature()
+	if len(sig) == 0 {
+		return fmt.Errorf("%w: tx is not signed", ErrInvalidSignature)
+	}
+	ok, err := pk.Verify(sig, msgHash, mimc.NewMiMC())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyTxOfAccount verifies tx against the public key of accountName.
+func VerifyTxOfAccount(accountName string, tx SignableTx) error {
+	return VerifyTxOfAccountWithContext(context.Background(), accountName, tx)
+}
+
+func VerifyTxOfAccountWithContext(ctx context.Context, accountName string, tx SignableTx) error {
+	return verifyTxOfAccount(ctx, GetConfig(), accountName, tx)
+}
+
+func verifyTxOfAccount(ctx context.Context, cfg *Config, accountName string, tx SignableTx) error {
+	account, err := getAccountByAccountName(ctx, cfg, accountName)
+	if err != nil {
+		return err
+	}
+	return VerifyTx(account.Account.AccountPk, tx)
+}
+
+// VerifyOffer checks that a pending offer, e.g. from GetNftOffers, is signed
+// by the account that listed it for the listed nft, side and price, and is not
+// expired. It returns the signed offer.
+func VerifyOffer(offer *Offer) (*OfferTxInfo, error) {
+	return VerifyOfferWithContext(context.Background(), offer)
+}
+
+func VerifyOfferWithContext(ctx context.Context, offer *Offer) (*OfferTxInfo, error) {
+	return verifyOffer(ctx, GetConfig(), offer)
+}
+
+func verifyOffer(ctx context.Context, cfg *Config, offer *Offer) (*OfferTxInfo, error) {
+	tx, err := ParseOfferTxInfo(offer.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: offer %d: %v", ErrInvalidSignature, offer.Id, err)
+	}
+	if tx == nil || tx.AssetAmount == nil {
+		return nil, fmt.Errorf("%w: offer %d is not signed", ErrInvalidSignature, offer.Id)
+	}
+	if tx.AssetId != offer.PaymentAssetId || tx.AssetAmount.String() != offer.PaymentAssetAmount {
+		return nil, fmt.Errorf("%w: offer %d signs %s of asset %d, listed %s of asset %d", ErrInvalidSignature,
+			offer.Id, tx.AssetAmount, tx.AssetId, offer.PaymentAssetAmount, offer.PaymentAssetId)
+	}
+	if tx.OfferId != offer.L2OfferId {
+		return nil, fmt.Errorf("%w: offer %d signs offer id %d, listed %d", ErrInvalidSignature, offer.Id, tx.OfferId, offer.L2OfferId)
+	}
+	// direction 1 lists a sell offer, signed with type 1
+	if isSell := offer.Direction == "1"; (tx.Type == 1) != isSell {
+		return nil, fmt.Errorf("%w: offer %d signs type %d, listed direction %q", ErrInvalidSignature, offer.Id, tx.Type, offer.Direction)
+	}
+	if tx.ExpiredAt <= time.Now().UnixMilli() {
+		return nil, fmt.Errorf("%w: offer %d expired at %d", ErrOfferExpired, offer.Id, tx.ExpiredAt)
+	}
+	nft, err := getNft(ctx, cfg, offer.AssetId)
+	if err != nil {
+		return nil, err
+	}
+	if tx.NftIndex != nft.NftIndex {
+		return nil, fmt.Errorf("%w: offer %d signs nft %d, listed asset %d is nft %d", ErrInvalidSignature, offer.Id, tx.NftIndex, offer.AssetId, nft.NftIndex)
+	}
+	account, err := getAccountByAccountName(ctx, cfg, offer.AccountName)
+	if err != nil {
+		return nil, err
+	}
+	if tx.AccountIndex != account.Account.AccountIndex {
+		return nil, fmt.Errorf("%w: offer %d signs account %d, listed by %s (%d)", ErrInvalidSignature,
+			offer.Id, tx.AccountIndex, offer.AccountName, account.Account.AccountIndex)
+	}
+	if err := VerifyTx(account.Account.AccountPk, tx); err != nil {
+		return nil, fmt.Errorf("offer %d: %w", offer.Id, err)
+	}
+	return tx, nil
+}
+
+func parseAccountPk(accountPk string) (*eddsa.PublicKey, error) {
+	pkBytes, err := hex.DecodeString(strings.TrimPrefix(accountPk, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid account pk: %v", err)
+	}
+	pk := &eddsa.PublicKey{}
+	if _, err := pk.SetBytes(pkBytes); err != nil {
+		return nil, fmt.Errorf("invalid account pk: %v", err)
+	}
+	return pk, nil
+}
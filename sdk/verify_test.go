@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyTx(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewSeedKeyManager("0x" + strings.Repeat("ef", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountPk := l2pkOfKey(key)
+
+	expiredAt := time.Now().Add(time.Hour).UnixMilli()
+	signed, err := ConstructOfferTx(key, &OfferTxInfo{Type: 0, OfferId: 3, AccountIndex: 1, NftIndex: 9, AssetId: 0, AssetAmount: big.NewInt(100), ExpiredAt: expiredAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	offer, err := ParseOfferTxInfo(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyOfferTx(accountPk, offer); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyOfferTx(l2pkOfKey(other), offer); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("signature of another key accepted: %v", err)
+	}
+	offer.AssetAmount = big.NewInt(1)
+	if err := VerifyOfferTx(accountPk, offer); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered offer accepted: %v", err)
+	}
+	if err := VerifyTransferNftTx(accountPk, &TransferNftTxInfo{GasFeeAssetAmount: big.NewInt(1)}); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("unsigned tx accepted: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/account/getAccountByAccountName":
+			index := map[string]int64{"alice": 1, "bob": 2}[r.URL.Query().Get("account_name")]
+			fmt.Fprintf(w, `{"account": {"account_index": %d, "account_pk": "%s"}}`, index, accountPk)
+		case "/api/v1/action/actionGetAssetByAssetId":
+			req := &ReqGetAssetById{}
+			json.NewDecoder(r.Body).Decode(req)
+			fmt.Fprintf(w, `{"asset": {"id": %d, "nft_index": %d}}`, req.Input.AssetId, req.Input.AssetId-3)
+		case "/api/v1/action/actionGetAssetOffers":
+			// like hasura, answer only the fields the query selects
+			req := &ReqGetAssetOffers{}
+			json.NewDecoder(r.Body).Decode(req)
+			pending := map[string]interface{}{"id": 1, "account_name": "alice", "direction": "0", "asset_id": req.Input.AssetId,
+				"payment_asset_id": 0, "payment_asset_amount": "100", "signature": signed, "status": "0"}
+			if strings.Contains(req.RequestQuery, "l2_offer_id") {
+				pending["l2_offer_id"] = 3
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"confirmedOfferIdList": []int64{}, "pendingOffers": []interface{}{pending}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	old := GetConfig()
+	defer SetConfig(old)
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+	if err := SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	offers, err := GetNftOffers(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers.PendingOffers) != 1 {
+		t.Fatalf("unexpected offers %+v", offers)
+	}
+	listed := &offers.PendingOffers[0]
+	if _, err := VerifyOffer(listed); err != nil {
+		t.Fatal(err)
+	}
+	for name, tamper := range map[string]func(o *Offer){
+		"price":    func(o *Offer) { o.PaymentAssetAmount = "10" },
+		"offer id": func(o *Offer) { o.L2OfferId = 4 },
+		"side":     func(o *Offer) { o.Direction = "1" },
+		"nft":      func(o *Offer) { o.AssetId = 13 },
+		"account":  func(o *Offer) { o.AccountName = "bob" },
+	} {
+		tampered := *listed
+		tamper(&tampered)
+		if _, err := VerifyOffer(&tampered); err == nil {
+			t.Fatalf("offer listed with another %s accepted", name)
+		}
+	}
+	expired, err := ConstructOfferTx(key, &OfferTxInfo{OfferId: 3, AccountIndex: 1, NftIndex: 9, AssetAmount: big.NewInt(100), ExpiredAt: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed.Signature = expired
+	if _, err := VerifyOffer(listed); !errors.Is(err, ErrOfferExpired) {
+		t.Fatalf("expired offer accepted: %v", err)
+	}
+}
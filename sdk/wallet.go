@@ -0,0 +1,315 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zecrey-labs/zecrey-eth-rpc/_rpc"
+)
+
+const defaultWalletConcurrency = 8
+
+var ErrUnknownAccount = errors.New("unknown wallet account")
+
+// AccountErrors holds the errors of a bulk wallet operation by account name.
+type AccountErrors map[string]error
+
+func (e AccountErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, e[name]))
+	}
+	return fmt.Sprintf("%d accounts failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Wallet holds many named accounts, one keystore file per account in Dir,
+// and routes operations to the client of each account.
+type Wallet struct {
+	Dir string
+	// Concurrency bounds the accounts processed at once by the bulk
+	// operations, defaults to 8.
+	Concurrency int
+	ScryptN     int
+	ScryptP     int
+
+	cfg *Config
+
+	lock           sync.Mutex
+	keys           map[string]*KeystoreKeyManager
+	clients        map[string]*client
+	providerClient *_rpc.ProviderClient
+}
+
+// NewWallet returns the wallet of the keystores in dir, call Unlock to load them.
+func NewWallet(cfg *Config, dir string) *Wallet {
+	return &Wallet{
+		Dir:     dir,
+		ScryptN: keystore.StandardScryptN,
+		ScryptP: keystore.StandardScryptP,
+		cfg:     cfg,
+		keys:    make(map[string]*KeystoreKeyManager),
+		clients: make(map[string]*client),
+	}
+}
+
+// Unlock decrypts every keystore of the wallet with password. The keys it
+// reloads replace, and zero, the unlocked ones.
+func (w *Wallet) Unlock(password string) error {
+	files, err := filepath.Glob(filepath.Join(w.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]*KeystoreKeyManager, len(files))
+	for _, file := range files {
+		key, err := NewKeystore(file).Load(password)
+		if err != nil {
+			for _, key := range keys {
+				key.Close()
+			}
+			return fmt.Errorf("unlock %s: %v", file, err)
+		}
+		name := key.AccountName()
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+		keys[name] = key
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for name, key := range keys {
+		w.replace(name, key)
+	}
+	return nil
+}
+
+// CreateAccount creates the keys of a new account, see Keystore.Create.
+func (w *Wallet) CreateAccount(accountName, password string) (*KeystoreKeyManager, error) {
+	ks, err := w.keystore(accountName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ks.Create(accountName, password, true)
+	if err != nil {
+		return nil, err
+	}
+	w.add(accountName, key)
+	return key, nil
+}
+
+// ImportAccount stores the keys of an existing account, see Keystore.Import.
+func (w *Wallet) ImportAccount(accountName, seed, l1PrivateKey, password string) (*KeystoreKeyManager, error) {
+	ks, err := w.keystore(accountName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ks.Import(accountName, seed, l1PrivateKey, password)
+	if err != nil {
+		return nil, err
+	}
+	w.add(accountName, key)
+	return key, nil
+}
+
+// Accounts returns the names of the unlocked accounts, sorted.
+func (w *Wallet) Accounts() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	names := make([]string, 0, len(w.keys))
+	for name := range w.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Key returns the keys of accountName.
+func (w *Wallet) Key(accountName string) (*KeystoreKeyManager, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	key, ok := w.keys[accountName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, accountName)
+	}
+	return key, nil
+}
+
+// Client returns the marketplace client of accountName, signing with its keys.
+func (w *Wallet) Client(ctx context.Context, accountName string) (ZecreyNftMarketSDK, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if c, ok := w.clients[accountName]; ok {
+		return c, nil
+	}
+	key, ok := w.keys[accountName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, accountName)
+	}
+	if w.providerClient == nil {
+		if err := w.cfg.Validate(); err != nil {
+			return nil, err
+		}
+		providerClient, err := dialChain(ctx, w.cfg)
+		if err != nil {
+			return nil, err
+		}
+		w.providerClient = providerClient
+	}
+	c := newClientWithKeyManager(w.cfg, w.providerClient, accountName, key, key.L2Pk())
+	w.clients[accountName] = c
+	return c, nil
+}
+
+// ForEach calls fn with the client of every account of accountNames, all
+// accounts when empty, running up to Concurrency calls at once. It returns
+// the failed accounts as AccountErrors.
+func (w *Wallet) ForEach(ctx context.Context, accountNames []string, fn func(ctx context.Context, accountName string, c ZecreyNftMarketSDK) error) error {
+	return w.each(ctx, accountNames, func(ctx context.Context, accountName string) error {
+		c, err := w.Client(ctx, accountName)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, accountName, c)
+	})
+}
+
+// AccountIndexes looks up the account index of every account of accountNames,
+// all accounts when empty. Failed lookups are returned as AccountErrors, next
+// to the indexes found.
+func (w *Wallet) AccountIndexes(ctx context.Context, accountNames []string) (map[string]int64, error) {
+	var lock sync.Mutex
+	indexes := make(map[string]int64)
+	err := w.each(ctx, accountNames, func(ctx context.Context, accountName string) error {
+		index, err := getAccountIndex(ctx, w.cfg, accountName)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		indexes[accountName] = index
+		lock.Unlock()
+		return nil
+	})
+	return indexes, err
+}
+
+// L1Addresses looks up the L1 address registered for every account of
+// accountNames, all accounts when empty, like AccountIndexes.
+func (w *Wallet) L1Addresses(ctx context.Context, accountNames []string) (map[string]common.Address, error) {
+	zecreyInstance, err := loadZecreyLegend(ctx, w.cfg)
+	if err != nil {
+		return nil, err
+	}
+	var lock sync.Mutex
+	addresses := make(map[string]common.Address)
+	err = w.each(ctx, accountNames, func(ctx context.Context, accountName string) error {
+		address, err := getAccountL1Address(ctx, w.cfg, zecreyInstance, accountName)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		addresses[accountName] = address
+		lock.Unlock()
+		return nil
+	})
+	return addresses, err
+}
+
+func (w *Wallet) each(ctx context.Context, accountNames []string, fn func(ctx context.Context, accountName string) error) error {
+	if len(accountNames) == 0 {
+		accountNames = w.Accounts()
+	}
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultWalletConcurrency
+	}
+	var (
+		lock sync.Mutex
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, concurrency)
+	)
+	errs := make(AccountErrors)
+	for _, name := range accountNames {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			lock.Lock()
+			errs[name] = ctx.Err()
+			lock.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(ctx, name); err != nil {
+				lock.Lock()
+				errs[name] = err
+				lock.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Close zeroes the keys of every account and closes the chain connection.
+// The clients of the wallet can not sign afterwards.
+func (w *Wallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for name, c := range w.clients {
+		c.Close()
+		delete(w.clients, name)
+	}
+	for name, key := range w.keys {
+		key.Close()
+		delete(w.keys, name)
+	}
+	if w.providerClient != nil {
+		w.providerClient.Close()
+		w.providerClient = nil
+	}
+	return nil
+}
+
+func (w *Wallet) keystore(accountName string) (*Keystore, error) {
+	if accountName == "" || accountName != filepath.Base(accountName) || strings.HasPrefix(accountName, ".") {
+		return nil, fmt.Errorf("invalid account name %q", accountName)
+	}
+	return &Keystore{Path: filepath.Join(w.Dir, accountName+".json"), ScryptN: w.ScryptN, ScryptP: w.ScryptP}, nil
+}
+
+func (w *Wallet) add(accountName string, key *KeystoreKeyManager) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.replace(accountName, key)
+}
+
+// replace sets the key of accountName, closing the key it replaces and the
+// client signing with it. It must be called with w.lock held.
+func (w *Wallet) replace(accountName string, key *KeystoreKeyManager) {
+	if c, ok := w.clients[accountName]; ok {
+		c.Close()
+		delete(w.clients, accountName)
+	}
+	if old, ok := w.keys[accountName]; ok && old != key {
+		old.Close()
+	}
+	w.keys[accountName] = key
+}
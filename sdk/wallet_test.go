@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+func TestWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zecrey-wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch name := r.URL.Query().Get("account_name"); name {
+		case "alice":
+			w.Write([]byte(`{"account": {"account_index": 1}}`))
+		case "bob":
+			w.Write([]byte(`{"account": {"account_index": 2}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 404, "message": "account not found"}`))
+		}
+	}))
+	defer server.Close()
+	cfg := DefaultConfig()
+	cfg.Retry = NoRetry
+	cfg.NftMarketUrl = server.URL
+
+	newWallet := func() *Wallet {
+		w := NewWallet(cfg, dir)
+		w.ScryptN, w.ScryptP = keystore.LightScryptN, keystore.LightScryptP
+		return w
+	}
+	w := newWallet()
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, err := w.CreateAccount(name, "pass"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := w.CreateAccount("../eve", "pass"); err == nil {
+		t.Fatal("account name escaping the wallet dir accepted")
+	}
+
+	reopened := newWallet()
+	if err := reopened.Unlock("pass"); err != nil {
+		t.Fatal(err)
+	}
+	if accounts := reopened.Accounts(); fmt.Sprint(accounts) != "[alice bob carol]" {
+		t.Fatalf("unexpected accounts %v", accounts)
+	}
+	indexes, err := reopened.AccountIndexes(context.Background(), nil)
+	var errs AccountErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs["carol"] == nil {
+		t.Fatalf("expected carol to fail, got %v", err)
+	}
+	if indexes["alice"] != 1 || indexes["bob"] != 2 {
+		t.Fatalf("unexpected indexes %v", indexes)
+	}
+
+	var running, peak int32
+	reopened.Concurrency = 2
+	err = reopened.each(context.Background(), nil, func(ctx context.Context, accountName string) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil || peak > 2 {
+		t.Fatalf("unexpected concurrency %d: %v", peak, err)
+	}
+	if _, err := reopened.Key("dave"); !errors.Is(err, ErrUnknownAccount) {
+		t.Fatalf("expected ErrUnknownAccount, got %v", err)
+	}
+
+	// unlocking again zeroes the replaced keys and drops their clients
+	oldKey, err := reopened.Key("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldClient, err := reopened.Client(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Unlock("pass"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oldKey.Sign([]byte("hash"), nil); !errors.Is(err, ErrKeyClosed) {
+		t.Fatalf("replaced key still signs: %v", err)
+	}
+	if _, err := oldClient.SignTx([]byte("hash")); !errors.Is(err, ErrKeyClosed) {
+		t.Fatalf("client of the replaced key still signs: %v", err)
+	}
+	newClient, err := reopened.Client(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newClient.SignTx([]byte("hash")); err != nil {
+		t.Fatal(err)
+	}
+	reopened.Close()
+}
@@ -0,0 +1,191 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrInvalidL1Address is returned for addresses without a valid EIP-55 checksum.
+	ErrInvalidL1Address = errors.New("invalid l1 address")
+	ErrWithdrawFailed   = errors.New("withdraw failed")
+)
+
+// txStatusFailed is the legend status of txs rejected by the committer.
+const txStatusFailed = 2
+
+type WithdrawState int
+
+const (
+	// WithdrawPending withdrawals are accepted by the legend but not yet in a committed block.
+	WithdrawPending WithdrawState = iota
+	// WithdrawCommitted withdrawals are in a block committed to L1, waiting for its proof.
+	WithdrawCommitted
+	// WithdrawClaimable withdrawals are in a verified and executed block: the
+	// amount is sent to the L1 address or left in its pending balance of the
+	// legend contract, to claim with withdrawPendingBalance.
+	WithdrawClaimable
+	WithdrawFailed
+)
+
+func (s WithdrawState) String() string {
+	switch s {
+	case WithdrawPending:
+		return "pending"
+	case WithdrawCommitted:
+		return "committed"
+	case WithdrawClaimable:
+		return "claimable"
+	case WithdrawFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("WithdrawState(%d)", int(s))
+}
+
+type WithdrawStatus struct {
+	TxId  string
+	State WithdrawState
+	Tx    *Tx
+}
+
+// ValidateL1Address parses a hex L1 address, which must carry its EIP-55
+// checksum: a mistyped withdraw address can not be recovered.
+func ValidateL1Address(address string) (common.Address, error) {
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("%w: %q", ErrInvalidL1Address, address)
+	}
+	addr := common.HexToAddress(address)
+	if addr.Hex() != address {
+		return common.Address{}, fmt.Errorf("%w: %q does not match its checksum address %s", ErrInvalidL1Address, address, addr.Hex())
+	}
+	return addr, nil
+}
+
+// Withdraw withdraws amount of the fungible asset assetId to l1Address. Track
+// the withdrawal with GetWithdrawStatus or WaitWithdrawClaimable.
+func (c *client) Withdraw(assetId int64, amount *big.Int, l1Address string) (*RespSendTx, error) {
+	return c.WithdrawWithContext(context.Background(), assetId, amount, l1Address)
+}
+
+func (c *client) WithdrawWithContext(ctx context.Context, assetId int64, amount *big.Int, l1Address string) (_ *RespSendTx, err error) {
+	ctx, op := c.cfg.telemetry().startOperation(ctx, "Withdraw", attribute.String("account_name", c.accountName))
+	defer op.end(&err)
+
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("withdraw amount must be positive")
+	}
+	toAddress, err := ValidateL1Address(l1Address)
+	if err != nil {
+		return nil, err
+	}
+	fromAccountIndex, err := getAccountIndex(ctx, c.cfg, c.accountName)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := selectGasFee(ctx, c.cfg, TxTypeWithdraw, assetId)
+	if err != nil {
+		return nil, err
+	}
+	tx := &WithdrawTxInfo{
+		FromAccountIndex:  fromAccountIndex,
+		AssetId:           assetId,
+		AssetAmount:       amount,
+		GasAccountIndex:   fee.AccountIndex,
+		GasFeeAssetId:     fee.AssetId,
+		GasFeeAssetAmount: fee.Amount,
+		ToAddress:         toAddress.Hex(),
+		ExpiredAt:         time.Now().Add(defaultTxExpiry).UnixMilli(),
+	}
+	return c.sendTx(ctx, fromAccountIndex, tx, func(nonce int64) { tx.Nonce = nonce })
+}
+
+// GetWithdrawStatus returns the status of the withdrawal txId with the config of c.
+func (c *client) GetWithdrawStatus(txId string) (*WithdrawStatus, error) {
+	return c.GetWithdrawStatusWithContext(context.Background(), txId)
+}
+
+func (c *client) GetWithdrawStatusWithContext(ctx context.Context, txId string) (*WithdrawStatus, error) {
+	return getWithdrawStatus(ctx, c.cfg, txId)
+}
+
+// WaitWithdrawClaimable is the package level WaitWithdrawClaimable with the
+// config of c.
+func (c *client) WaitWithdrawClaimable(txId string, pollInterval time.Duration) (*WithdrawStatus, error) {
+	return c.WaitWithdrawClaimableWithContext(context.Background(), txId, pollInterval)
+}
+
+func (c *client) WaitWithdrawClaimableWithContext(ctx context.Context, txId string, pollInterval time.Duration) (*WithdrawStatus, error) {
+	return waitWithdrawClaimable(ctx, c.cfg, txId, pollInterval)
+}
+
+func GetWithdrawStatus(txId string) (*WithdrawStatus, error) {
+	return GetWithdrawStatusWithContext(context.Background(), txId)
+}
+
+func GetWithdrawStatusWithContext(ctx context.Context, txId string) (*WithdrawStatus, error) {
+	return getWithdrawStatus(ctx, GetConfig(), txId)
+}
+
+// WaitWithdrawClaimable polls the status of the withdrawal txId every
+// pollInterval, which must be positive, until it is claimable on L1. It fails
+// with ErrWithdrawFailed when the legend rejects the withdrawal.
+func WaitWithdrawClaimable(txId string, pollInterval time.Duration) (*WithdrawStatus, error) {
+	return WaitWithdrawClaimableWithContext(context.Background(), txId, pollInterval)
+}
+
+func WaitWithdrawClaimableWithContext(ctx context.Context, txId string, pollInterval time.Duration) (*WithdrawStatus, error) {
+	return waitWithdrawClaimable(ctx, GetConfig(), txId, pollInterval)
+}
+
+func waitWithdrawClaimable(ctx context.Context, cfg *Config, txId string, pollInterval time.Duration) (*WithdrawStatus, error) {
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid poll interval %s", pollInterval)
+	}
+	for {
+		status, err := getWithdrawStatus(ctx, cfg, txId)
+		if err != nil {
+			return nil, err
+		}
+		switch status.State {
+		case WithdrawClaimable:
+			return status, nil
+		case WithdrawFailed:
+			return status, fmt.Errorf("%w: tx %s", ErrWithdrawFailed, txId)
+		}
+		cfg.logger().Debug("waiting for withdraw", "tx_id", txId, "state", status.State.String())
+		timer := time.NewTimer(pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func getWithdrawStatus(ctx context.Context, cfg *Config, txId string) (*WithdrawStatus, error) {
+	result := &RespGetTxByHash{}
+	if err := httpGet(ctx, cfg, cfg.LegendUrl+"/api/v1/tx/getTxByHash?tx_hash="+url.QueryEscape(txId), result); err != nil {
+		return nil, err
+	}
+	if result.Tx.TxType != 0 && result.Tx.TxType != TxTypeWithdraw {
+		return nil, fmt.Errorf("tx %s is not a withdraw", txId)
+	}
+	status := &WithdrawStatus{TxId: txId, State: WithdrawPending, Tx: &result.Tx}
+	switch {
+	case result.Tx.TxStatus == txStatusFailed:
+		status.State = WithdrawFailed
+	case result.ExecutedAt > 0:
+		status.State = WithdrawClaimable
+	case result.CommittedAt > 0:
+		status.State = WithdrawCommitted
+	}
+	return status, nil
+}
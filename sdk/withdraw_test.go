@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithdraw(t *testing.T) {
+	key, err := NewSeedKeyManager("0x" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const l1Address = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	var (
+		lock  sync.Mutex
+		sent  *WithdrawTxInfo
+		polls int
+	)
+	cfg := newLegendTestConfig(t, map[string]http.HandlerFunc{
+		"/api/v1/tx/getNextNonce": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"nonce": 4}`))
+		},
+		"/api/v1/tx/sendTx": func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			r.ParseForm()
+			tx := &WithdrawTxInfo{}
+			if r.Form.Get("tx_type") != fmt.Sprint(TxTypeWithdraw) || json.Unmarshal([]byte(r.Form.Get("tx_info")), tx) != nil ||
+				VerifyWithdrawTx(l2pkOfKey(key), tx) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code": 400, "message": "invalid tx"}`))
+				return
+			}
+			sent = tx
+			w.Write([]byte(`{"tx_id": "0xwithdraw"}`))
+		},
+		"/api/v1/tx/getTxByHash": func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			if r.URL.Query().Get("tx_hash") == "0xfailed" {
+				fmt.Fprintf(w, `{"tx": {"tx_type": %d, "tx_status": 2}}`, TxTypeWithdraw)
+				return
+			}
+			// pending, then committed, then verified and executed
+			polls++
+			fmt.Fprintf(w, `{"tx": {"tx_type": %d, "tx_status": 1, "block_height": 3}, "committed_at": %d, "executed_at": %d}`,
+				TxTypeWithdraw, map[bool]int{true: 1}[polls > 1], map[bool]int{true: 1}[polls > 2])
+		},
+	})
+	old := GetConfig()
+	defer SetConfig(old)
+	if err := SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	c := newClientWithKeyManager(cfg, nil, "alice", key, l2pkOfKey(key))
+
+	for _, addr := range []string{strings.ToLower(l1Address), strings.Replace(l1Address, "a", "A", 1), "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA"} {
+		if _, err := c.Withdraw(0, big.NewInt(1000), addr); !errors.Is(err, ErrInvalidL1Address) {
+			t.Fatalf("withdraw to %s accepted: %v", addr, err)
+		}
+	}
+	if sent != nil {
+		t.Fatal("withdraw sent to an invalid address")
+	}
+	resp, err := c.Withdraw(0, big.NewInt(1000), l1Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil || sent.FromAccountIndex != 1 || sent.ToAddress != l1Address || sent.AssetAmount.Int64() != 1000 ||
+		sent.Nonce != 4 || sent.GasAccountIndex != 9 || sent.GasFeeAssetAmount.Int64() != 100 {
+		t.Fatalf("unexpected withdraw %+v", sent)
+	}
+
+	status, err := GetWithdrawStatus(resp.TxId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != WithdrawPending {
+		t.Fatalf("unexpected state %s", status.State)
+	}
+	status, err = WaitWithdrawClaimable(resp.TxId, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != WithdrawClaimable || polls != 3 || status.Tx.BlockHeight != 3 {
+		t.Fatalf("unexpected state %s after %d polls", status.State, polls)
+	}
+	if _, err := WaitWithdrawClaimable(resp.TxId, 0); err == nil {
+		t.Fatal("non-positive poll interval accepted")
+	}
+	// clients track their withdrawals with their own config
+	SetConfig(old)
+	if status, err := c.WaitWithdrawClaimable(resp.TxId, time.Millisecond); err != nil || status.State != WithdrawClaimable {
+		t.Fatalf("client wait failed: %v", err)
+	}
+	SetConfig(cfg)
+	if _, err := WaitWithdrawClaimable("0xfailed", time.Millisecond); !errors.Is(err, ErrWithdrawFailed) {
+		t.Fatalf("failed withdraw not reported: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	lock.Lock()
+	polls = 0
+	lock.Unlock()
+	if _, err := WaitWithdrawClaimableWithContext(ctx, resp.TxId, time.Hour); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait not canceled: %v", err)
+	}
+}